@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/0xmukesh/insightly/internal/commands"
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -18,13 +22,51 @@ func Execute() error {
 		},
 	}
 
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "Skip confirmation prompts")
+	rootCmd.PersistentFlags().String("config", "", "Path of the config file (default is $XDG_CONFIG_HOME/insightly/config.json)")
+	rootCmd.PersistentFlags().String("profile", "", "Profile which is used for this run (default is the profile selected via `config profile use`)")
+
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		configFilePath, _ := cmd.Flags().GetString("config")
+		helpers.SetConfigFilePath(configFilePath)
+
+		profile, _ := cmd.Flags().GetString("profile")
+		helpers.SetProfile(profile)
+
+		migrated, err := helpers.MigrateLegacyFiles()
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		for _, migration := range migrated {
+			// stdout is left to the reports, e.g. `gen-ux -o -`
+			fmt.Fprintln(os.Stderr, migration)
+		}
+	}
+
 	genUxCmd := commands.GenerateUxReportCmd{}
 	setupCmd := commands.SetupCmd{}
 	configCmd := commands.ConfigCmd{}
+	chatCmd := commands.ChatCmd{}
+	historyCmd := commands.HistoryCmd{}
+	trendCmd := commands.TrendCmd{}
+	diffCmd := commands.DiffCmd{}
+	compareCmd := commands.CompareCmd{}
+	queryCmd := commands.QueryCmd{}
+	triageCmd := commands.TriageCmd{}
+	exportCmd := commands.ExportCmd{}
 
 	rootCmd.AddCommand(genUxCmd.New())
 	rootCmd.AddCommand(setupCmd.New())
 	rootCmd.AddCommand(configCmd.New())
+	rootCmd.AddCommand(chatCmd.New())
+	rootCmd.AddCommand(historyCmd.New())
+	rootCmd.AddCommand(trendCmd.New())
+	rootCmd.AddCommand(diffCmd.New())
+	rootCmd.AddCommand(compareCmd.New())
+	rootCmd.AddCommand(queryCmd.New())
+	rootCmd.AddCommand(triageCmd.New())
+	rootCmd.AddCommand(exportCmd.New())
 
 	return rootCmd.ExecuteContext(context.Background())
 }
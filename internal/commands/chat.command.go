@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/tui"
+	"github.com/0xmukesh/insightly/internal/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+type ChatCmd struct {
+	BaseCmd
+}
+
+func (c ChatCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "chat",
+		Short:   "Ask follow-up questions about a saved report",
+		Example: "insightly chat [history-id]",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("llm", "", "Use any other LLM than the one which generated the summary")
+
+	return cmd
+}
+
+func (c ChatCmd) Handler() {
+	cmd := c.Cmd
+	args := c.Args
+
+	nonDefaultLlm, _ := cmd.Flags().GetString("llm")
+
+	config, err := helpers.LoadConfig()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("❌ It seems like you're trying to run `chat` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	historyId := "latest"
+	if len(args) == 1 {
+		historyId = args[0]
+	}
+
+	entry, summary, err := helpers.ReadHistoryEntry(historyId)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	llm := config.Default
+	if nonDefaultLlm != "" {
+		llm = helpers.Llm(nonDefaultLlm)
+	} else if entry.Llm != "" {
+		llm = entry.Llm
+	}
+
+	if !helpers.IsSupportedLlm(llm) {
+		utils.LogF(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", llm))
+	}
+
+	key, err := helpers.GetLlmKey(string(llm))
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	// history keeps the raw report, so it is redacted the same way as when it was summarized
+	if !config.Redaction.Disabled {
+		redactor, err := helpers.NewRedactor(config.Redaction.Patterns)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		entry.Report, _ = redactor.Redact(entry.Report)
+	}
+
+	historyId = entry.Id
+
+	turns, err := helpers.ReadChatTranscript(historyId)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	ask := func(turns []helpers.ChatTurn) (string, error) {
+		return helpers.QueryLlm(llm, key, helpers.BuildChatPrompt(llm, entry, summary, turns))
+	}
+
+	save := func(turns []helpers.ChatTurn) error {
+		return helpers.WriteChatTranscript(historyId, turns)
+	}
+
+	title := fmt.Sprintf("insightly chat - %s", historyId)
+	if entry.Url != "" {
+		title = fmt.Sprintf("%s (%s)", title, entry.Url)
+	}
+
+	model, err := tea.NewProgram(tui.NewChatModel(title, turns, ask, save), tea.WithAltScreen()).Run()
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	chatModel, ok := model.(tui.ChatModel)
+	if !ok {
+		return
+	}
+
+	// errors of earlier questions were already shown in the chat, only a transcript which couldn't be saved is reported
+	if chatModel.SaveErr() != nil {
+		utils.LogF(fmt.Sprintf("❌ Failed to save the chat transcript: %s", chatModel.SaveErr()))
+	}
+
+	if chatModel.Saved() {
+		fmt.Printf("Saved the chat transcript to `%s`\n", helpers.GetChatTranscriptFilePath(historyId))
+	}
+}
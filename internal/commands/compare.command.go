@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type CompareCmd struct {
+	BaseCmd
+}
+
+func (c CompareCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "compare",
+		Short:   "Audit two websites and compare the first one against the second one, e.g. staging against production",
+		Example: "insightly compare <website-url> <baseline-url> [--use-pa11y] [--from-history] [--format text|markdown|json]",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("use-pa11y", "", false, "Use pa11y instead of lighthouse")
+	cmd.Flags().String("standard", "", "Accessibility standard which pa11y audits against, e.g. WCAG2AA")
+	cmd.Flags().BoolP("from-history", "", false, "Compare the most recent runs of the websites from history instead of auditing them")
+	cmd.Flags().BoolP("no-history", "", false, "Don't save the runs to history")
+	addDiffFlags(cmd)
+
+	return cmd
+}
+
+func (c CompareCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	usePa11y, _ := flags.GetBool("use-pa11y")
+	fromHistory, _ := flags.GetBool("from-history")
+
+	opts := readDiffOptions(c.Cmd)
+
+	auditOpts := uxReportOptions{stdout: os.Stdout, status: os.Stdout}
+	auditOpts.standard, _ = flags.GetString("standard")
+	auditOpts.noHistory, _ = flags.GetBool("no-history")
+
+	tool := "lighthouse"
+	if usePa11y {
+		tool = "pa11y"
+	}
+
+	headUrl, baseUrl := c.Args[0], c.Args[1]
+
+	for _, websiteUrl := range c.Args {
+		if !utils.IsValidUrl(websiteUrl) {
+			utils.LogF(fmt.Sprintf("❌ Invalid website URL %s", websiteUrl))
+		}
+	}
+
+	var head, base helpers.HistoryEntry
+
+	if fromHistory {
+		var err error
+
+		head, err = helpers.FindLatestHistoryEntry(headUrl, tool)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		base, err = helpers.FindLatestHistoryEntry(baseUrl, tool)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+	} else {
+		projectConfig, _, err := helpers.FindProjectConfig()
+		if err != nil {
+			utils.LogF(fmt.Sprintf("❌ %s", err.Error()))
+		}
+
+		// both websites are audited with the same ignore rules, so that they are compared fairly. triage
+		// decisions are left out as they are made for a single URL
+		auditOpts.ignore = projectConfig.Ignore
+
+		if !flags.Changed("standard") {
+			auditOpts.standard = projectConfig.Pa11y.Standard
+		}
+
+		if !helpers.IsNodeInstalled() {
+			utils.LogF("❌ For running UX reports, Node.js must be installed")
+		}
+
+		// the LLMs don't have to be set up for comparing, only the redaction settings are read
+		config, err := helpers.ReadConfigFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			utils.LogF(err.Error())
+		}
+
+		redactor := newRedactor(config, auditOpts)
+		uxReportCmd := GenerateUxReportCmd{}
+
+		audit := func(websiteUrl string) helpers.HistoryEntry {
+			var result uxReportResult
+
+			if usePa11y {
+				result = uxReportCmd.runPa11y(websiteUrl, auditOpts)
+			} else {
+				result = uxReportCmd.runLighthouse(websiteUrl, auditOpts)
+			}
+
+			redactResult(&result, redactor)
+
+			return uxReportCmd.saveToHistory(result, auditOpts)
+		}
+
+		head = audit(headUrl)
+		base = audit(baseUrl)
+	}
+
+	diff := helpers.DiffRuns(base, head, opts.tolerance)
+
+	outputRunDiff(diff, baseUrl, headUrl, opts)
+}
@@ -4,10 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/0xmukesh/insightly/internal/helpers"
 	"github.com/0xmukesh/insightly/internal/helpers/styles"
 	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -27,6 +30,18 @@ type ConfigSetCmd struct {
 type ConfigRemoveCmd struct {
 	BaseCmd
 }
+type ConfigSetFallbackCmd struct {
+	BaseCmd
+}
+type ConfigSecureCmd struct {
+	BaseCmd
+}
+type ConfigDoctorCmd struct {
+	BaseCmd
+}
+type ConfigTestCmd struct {
+	BaseCmd
+}
 
 func (c ConfigCmd) New() *cobra.Command {
 	cmd := &cobra.Command{
@@ -46,11 +61,25 @@ func (c ConfigCmd) New() *cobra.Command {
 	configSetDefaultCmd := ConfigSetDefaultCmd{}
 	configSetCmd := ConfigSetCmd{}
 	configRemoveCmd := ConfigRemoveCmd{}
+	configSetFallbackCmd := ConfigSetFallbackCmd{}
+	configSecureCmd := ConfigSecureCmd{}
+	configDoctorCmd := ConfigDoctorCmd{}
+	configProfileCmd := ConfigProfileCmd{}
+	configExportCmd := ConfigExportCmd{}
+	configTestCmd := ConfigTestCmd{}
+	configImportCmd := ConfigImportCmd{}
 
 	cmd.AddCommand(configViewCmd.New())
 	cmd.AddCommand(configSetDefaultCmd.New())
 	cmd.AddCommand(configSetCmd.New())
 	cmd.AddCommand(configRemoveCmd.New())
+	cmd.AddCommand(configSetFallbackCmd.New())
+	cmd.AddCommand(configSecureCmd.New())
+	cmd.AddCommand(configDoctorCmd.New())
+	cmd.AddCommand(configProfileCmd.New())
+	cmd.AddCommand(configExportCmd.New())
+	cmd.AddCommand(configTestCmd.New())
+	cmd.AddCommand(configImportCmd.New())
 
 	return cmd
 }
@@ -80,7 +109,7 @@ func (c ConfigViewCmd) Handler() {
 
 	showFull, _ := cmd.Flags().GetBool("show-full")
 
-	config, err := helpers.ReadConfigFile()
+	config, err := helpers.LoadConfig()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			utils.LogF("It seems like you're trying to run `config view` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
@@ -93,19 +122,39 @@ func (c ConfigViewCmd) Handler() {
 		utils.LogF("It seems like you're trying to run `config view` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
 	}
 
+	if profile := helpers.GetProfileName(config); profile != helpers.DefaultProfile {
+		fmt.Printf("You're using %s profile\n", styles.BoldBlueTextStyle.Render(profile))
+	}
+
 	fmt.Printf("You're using %s as your default LLM\n", styles.BoldBlueTextStyle.Render(string(config.Default)))
+
+	if len(config.Fallback) != 0 {
+		var fallback []string
+		for i := range config.Fallback {
+			fallback = append(fallback, string(config.Fallback[i]))
+		}
+
+		fmt.Printf("If it fails, the prompt is sent to %s in that order\n", styles.BoldBlueTextStyle.Render(strings.Join(fallback, " -> ")))
+	}
+
 	fmt.Printf("Here are your configuration details for each of the LLM:\n")
 
 	for i := range config.Llms {
-		var key string
+		key, source, err := helpers.ResolveLlmKeyWithSource(config.Llms[i])
+		if err != nil {
+			fmt.Printf(">> %s - %s\n", config.Llms[i].Name, styles.BoldPinkTextStyle.Render(err.Error()))
+			continue
+		}
 
-		if showFull {
-			key = config.Llms[i].ApiKey
-		} else {
-			key = utils.MaskApiKey(config.Llms[i].ApiKey)
+		if !showFull {
+			key = utils.MaskApiKey(key)
 		}
 
-		fmt.Printf(">> %s - %s\n", config.Llms[i].Name, key)
+		fmt.Printf(">> %s - %s (from %s)\n", config.Llms[i].Name, key, source)
+	}
+
+	if helpers.HasPlaintextApiKeys(config) && helpers.GetKeyStore() != helpers.PlaintextStore {
+		fmt.Println("⚠️ Some of your API keys are stored in plaintext. Run `config secure` to move them to your OS keyring or an encrypted vault")
 	}
 }
 
@@ -146,6 +195,10 @@ func (c ConfigSetDefaultCmd) Handler() {
 		utils.LogF("It seems like you're trying to run `config set-default` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
 	}
 
+	if !utils.OneOfThem(newDefaultLlm, helpers.ValidLlms) {
+		utils.LogF(fmt.Sprintf("%s isn't a valid LLM. Valid LLMs are %s", newDefaultLlm, strings.Join(helpers.ValidLlms, ", ")))
+	}
+
 	if newDefaultLlm != "" {
 		found := false
 
@@ -171,7 +224,7 @@ func (c ConfigSetCmd) New() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "set",
 		Short:   "Update configuration details",
-		Example: "insightly config set",
+		Example: "insightly config set [llm...]\n  echo $GEMINI_API_KEY | insightly config set gemini --key-stdin",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Cmd = cmd
 			c.Args = args
@@ -182,10 +235,20 @@ func (c ConfigSetCmd) New() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolP("key-stdin", "", false, "Read the API key of the given LLM from stdin")
+	cmd.Flags().String("key-cmd", "", "Command whose output is used as the API key of the given LLM")
+	cmd.Flags().BoolP("skip-validation", "", false, "Don't validate the API keys against the LLMs' providers")
+
 	return cmd
 }
 
 func (c ConfigSetCmd) Handler() {
+	cmd := c.Cmd
+	args := c.Args
+
+	keyStdin, _ := cmd.Flags().GetBool("key-stdin")
+	keyCmd, _ := cmd.Flags().GetString("key-cmd")
+
 	config, err := helpers.ReadConfigFile()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -199,13 +262,67 @@ func (c ConfigSetCmd) Handler() {
 		utils.LogF("It seems like you're trying to run `config set` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
 	}
 
+	if keyStdin || keyCmd != "" {
+		if len(args) != 1 {
+			utils.LogF("Pass exactly one LLM along with `--key-stdin` or `--key-cmd`, e.g. `config set gemini --key-stdin`")
+		}
+
+		if keyStdin && keyCmd != "" {
+			utils.LogF("Pass either `--key-stdin` or `--key-cmd`")
+		}
+
+		found := false
+
+		for i := range config.Llms {
+			if string(config.Llms[i].Name) != args[0] {
+				continue
+			}
+
+			found = true
+
+			if keyStdin {
+				key, err := helpers.ReadKeyFromStdin()
+				if err != nil {
+					utils.LogF(err.Error())
+				}
+
+				if err := confirmApiKeys(cmd, []helpers.LlmConfig{{Name: config.Llms[i].Name, ApiKey: key}}); err != nil {
+					utils.LogF(err.Error())
+				}
+
+				if err := helpers.ReplaceLlmKey(&config.Llms[i], key, ""); err != nil {
+					utils.LogF(err.Error())
+				}
+			} else {
+				if err := confirmApiKeys(cmd, []helpers.LlmConfig{{Name: config.Llms[i].Name, ApiKeyCmd: keyCmd}}); err != nil {
+					utils.LogF(err.Error())
+				}
+
+				if err := helpers.ReplaceLlmKey(&config.Llms[i], "", keyCmd); err != nil {
+					utils.LogF(err.Error())
+				}
+			}
+		}
+
+		if !found {
+			utils.LogF(fmt.Sprintf("Can't update %s as its' configuration can't be found. Run `setup --llm %s` to add it", args[0], args[0]))
+		}
+
+		if err := helpers.WriteToConfigFile(config); err != nil {
+			utils.LogF(err.Error())
+		}
+
+		fmt.Printf("Successfully updated key for %s\n", args[0])
+		return
+	}
+
 	var llmsFormFields []huh.Option[string]
 
 	for i := range config.Llms {
 		llmsFormFields = append(llmsFormFields, huh.NewOption(string(config.Llms[i].Name), string(config.Llms[i].Name)))
 	}
 
-	var selectedLlms []string
+	selectedLlms := args
 
 	llmsForm := huh.NewForm(huh.NewGroup(
 		huh.NewNote().Title("insightly config set").Description("choose the models whose api key you would like to update"),
@@ -219,14 +336,35 @@ func (c ConfigSetCmd) Handler() {
 		}),
 	))
 
-	if err := llmsForm.Run(); err != nil {
-		utils.LogF(err.Error())
+	if len(selectedLlms) == 0 {
+		if err := helpers.RunForm(llmsForm); err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	for _, selected := range selectedLlms {
+		found := false
+
+		for i := range config.Llms {
+			if string(config.Llms[i].Name) == selected {
+				found = true
+			}
+		}
+
+		if !found {
+			utils.LogF(fmt.Sprintf("Can't update %s as its' configuration can't be found. Run `setup --llm %s` to add it", selected, selected))
+		}
 	}
 
 	var keysFormFields []huh.Field
+	keys := make([]string, len(config.Llms))
 
-	for i := range selectedLlms {
-		keysFormFields = append(keysFormFields, huh.NewInput().Title(fmt.Sprintf("input your api key for %s LLM", selectedLlms[i])).Value(&config.Llms[i].ApiKey).EchoMode(huh.EchoModePassword).Validate(func(s string) error {
+	for i := range config.Llms {
+		if !utils.OneOfThem(string(config.Llms[i].Name), selectedLlms) {
+			continue
+		}
+
+		keysFormFields = append(keysFormFields, huh.NewInput().Title(fmt.Sprintf("input your api key for %s LLM", config.Llms[i].Name)).Value(&keys[i]).EchoMode(huh.EchoModePassword).Validate(func(s string) error {
 			if len(s) == 0 {
 				return errors.New("input an API key")
 			}
@@ -238,10 +376,32 @@ func (c ConfigSetCmd) Handler() {
 
 	keysForm := huh.NewForm(huh.NewGroup(keysFormFields...))
 
-	if err := keysForm.Run(); err != nil {
+	if err := helpers.RunForm(keysForm); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	var updatedLlms []helpers.LlmConfig
+
+	for i := range config.Llms {
+		if utils.OneOfThem(string(config.Llms[i].Name), selectedLlms) {
+			updatedLlms = append(updatedLlms, helpers.LlmConfig{Name: config.Llms[i].Name, ApiKey: keys[i]})
+		}
+	}
+
+	if err := confirmApiKeys(cmd, updatedLlms); err != nil {
 		utils.LogF(err.Error())
 	}
 
+	for i := range config.Llms {
+		if !utils.OneOfThem(string(config.Llms[i].Name), selectedLlms) {
+			continue
+		}
+
+		if err := helpers.ReplaceLlmKey(&config.Llms[i], keys[i], ""); err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
 	if err := helpers.WriteToConfigFile(config); err != nil {
 		utils.LogF(err.Error())
 	}
@@ -253,7 +413,7 @@ func (c ConfigRemoveCmd) New() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "remove",
 		Short:   "Remove configuration details of a certain LLM",
-		Example: "insightly config remove",
+		Example: "insightly config remove [llm...]",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Cmd = cmd
 			c.Args = args
@@ -268,6 +428,8 @@ func (c ConfigRemoveCmd) New() *cobra.Command {
 }
 
 func (c ConfigRemoveCmd) Handler() {
+	assumeYes, _ := c.Cmd.Flags().GetBool("yes")
+
 	config, err := helpers.ReadConfigFile()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -287,10 +449,10 @@ func (c ConfigRemoveCmd) Handler() {
 		llmsFormFields = append(llmsFormFields, huh.NewOption(string(config.Llms[i].Name), string(config.Llms[i].Name)))
 	}
 
-	var selectedLlms []string
+	selectedLlms := c.Args
 
 	llmsForm := huh.NewForm(huh.NewGroup(
-		huh.NewNote().Title("something config remove").Description("choose the models whose configuration you would like to be removed"),
+		huh.NewNote().Title("insightly config remove").Description("choose the models whose configuration you would like to be removed"),
 		huh.NewMultiSelect[string]().Title("choose llms").Options(llmsFormFields...,
 		).Value(&selectedLlms).Filterable(true).Validate(func(s []string) error {
 			if len(s) == 0 {
@@ -301,13 +463,42 @@ func (c ConfigRemoveCmd) Handler() {
 		}),
 	))
 
-	if err := llmsForm.Run(); err != nil {
+	if len(selectedLlms) == 0 {
+		if err := helpers.RunForm(llmsForm); err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	for _, selected := range selectedLlms {
+		found := false
+
+		for i := range config.Llms {
+			if string(config.Llms[i].Name) == selected {
+				found = true
+			}
+		}
+
+		if !found {
+			utils.LogF(fmt.Sprintf("Can't remove %s as its' configuration can't be found", selected))
+		}
+	}
+
+	confirmed, err := helpers.Confirm(fmt.Sprintf("remove configuration of %s?", strings.Join(selectedLlms, ", ")), assumeYes)
+	if err != nil {
 		utils.LogF(err.Error())
 	}
 
+	if !confirmed {
+		return
+	}
+
 	for i := len(config.Llms) - 1; i >= 0; i-- {
 		for _, selected := range selectedLlms {
 			if config.Llms[i].Name == helpers.Llm(selected) {
+				if err := helpers.DeleteApiKeyRef(config.Llms[i].ApiKeyRef); err != nil {
+					utils.LogF(err.Error())
+				}
+
 				config.Llms = append(config.Llms[:i], config.Llms[i+1:]...)
 				break
 			}
@@ -318,3 +509,315 @@ func (c ConfigRemoveCmd) Handler() {
 		utils.LogF(err.Error())
 	}
 }
+
+func (c ConfigSetFallbackCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "set-fallback",
+		Short:   "Change the LLMs which are tried in order if your LLM fails",
+		Example: "insightly config set-fallback <llm> [llm...]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c ConfigSetFallbackCmd) Handler() {
+	args := c.Args
+
+	config, err := helpers.ReadConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("It seems like you're trying to run `config set-fallback` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	var fallback []helpers.Llm
+
+	for _, llm := range args {
+		if !utils.OneOfThem(llm, helpers.ValidLlms) {
+			utils.LogF(fmt.Sprintf("%s isn't a valid LLM. Valid LLMs are %s", llm, strings.Join(helpers.ValidLlms, ", ")))
+		}
+
+		found := false
+
+		for i := range config.Llms {
+			if llm == string(config.Llms[i].Name) {
+				found = true
+			}
+		}
+
+		if !found {
+			utils.LogF(fmt.Sprintf("Can't add %s to the fallback chain cause its' configuration can't be found. Run `config set` to set an LLM's configuration", llm))
+		}
+
+		if !helpers.IsSupportedLlm(helpers.Llm(llm)) {
+			utils.LogF(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", llm))
+		}
+
+		fallback = append(fallback, helpers.Llm(llm))
+	}
+
+	config.Fallback = fallback
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if len(fallback) == 0 {
+		fmt.Println("Cleared the fallback chain")
+	} else {
+		fmt.Printf("Successfully updated the fallback chain to %s\n", strings.Join(args, " -> "))
+	}
+}
+
+func (c ConfigSecureCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "secure",
+		Short:   "Move plaintext API keys to your OS keyring or an encrypted vault",
+		Example: "insightly config secure",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c ConfigSecureCmd) Handler() {
+	config, err := helpers.ReadConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("It seems like you're trying to run `config secure` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	store := helpers.GetKeyStore()
+	if store == helpers.PlaintextStore {
+		utils.LogF("Can't secure the API keys as `INSIGHTLY_KEY_STORE` is set to plaintext")
+	}
+
+	count, err := helpers.SecureApiKeys(config)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if count == 0 {
+		fmt.Println("There were no plaintext API keys in your config file")
+		return
+	}
+
+	fmt.Printf("Successfully moved %d API key(s) to the %s\n", count, store)
+}
+
+func (c ConfigDoctorCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Short:   "Check the config file for problems and repair them",
+		Example: "insightly config doctor [--fix]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("fix", "", false, "Repair the problems which can be fixed automatically")
+
+	return cmd
+}
+
+func (c ConfigDoctorCmd) Handler() {
+	fix, _ := c.Cmd.Flags().GetBool("fix")
+
+	fmt.Printf("Checking %s\n", styles.BoldBlueTextStyle.Render(helpers.GetConfigFilePath()))
+
+	config, applied, err := helpers.ReadRawConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("❌ Config file doesn't exist. Run `setup` to setup your LLM configuration")
+		}
+
+		var syntaxErr *helpers.ConfigSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			utils.LogF(fmt.Sprintf("❌ %s", err.Error()))
+		}
+
+		fmt.Printf("❌ %s\n", err.Error())
+
+		if !fix {
+			utils.LogF("Run `config doctor --fix` to restore the last valid config file from its backup")
+		}
+
+		restored, err := helpers.RestoreConfigBackup()
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		if !restored {
+			utils.LogF("There is no valid backup of the config file. Run `setup` to setup your LLM configuration again")
+		}
+
+		fmt.Println("✅ Restored the config file from its backup, run `config doctor` again to check it")
+		return
+	}
+
+	fmt.Println("✅ Config file is valid JSON")
+
+	if len(applied) != 0 {
+		for _, migration := range applied {
+			fmt.Printf("⬆️ Migrated config file to %s\n", migration)
+		}
+	} else {
+		fmt.Printf("✅ Config file is at the latest schema version (v%d)\n", helpers.CurrentConfigVersion)
+	}
+
+	problems := helpers.ValidateConfig(config)
+	fixable := 0
+
+	for _, problem := range problems {
+		icon := "❌"
+		if problem.Warning {
+			icon = "⚠️"
+		}
+
+		suffix := ""
+		if problem.Fixable {
+			suffix = " (fixable)"
+			fixable++
+		}
+
+		fmt.Printf("%s %s%s\n", icon, problem.Message, suffix)
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("✅ No problems found")
+	}
+
+	if !fix {
+		if fixable != 0 {
+			fmt.Printf("Run `config doctor --fix` to repair %d problem(s)\n", fixable)
+		}
+
+		if len(applied) == 0 && !helpers.HasConfigErrors(problems) {
+			return
+		}
+
+		if helpers.HasConfigErrors(problems) {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if err := helpers.WriteToConfigFile(helpers.RepairConfig(config)); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("✅ Repaired the config file, the previous version is saved as %s.bak\n", helpers.GetConfigFilePath())
+}
+
+func (c ConfigTestCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "test",
+		Short:   "Check whether the API keys are valid, expired or rate limited",
+		Example: "insightly config test [llm...]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c ConfigTestCmd) Handler() {
+	config, err := helpers.LoadConfig()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("It seems like you're trying to run `config test` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	if len(config.Llms) == 0 {
+		utils.LogF("It seems like you're trying to run `config test` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+	}
+
+	var llmConfigs []helpers.LlmConfig
+
+	for _, llm := range c.Args {
+		found := false
+
+		for i := range config.Llms {
+			if string(config.Llms[i].Name) == llm {
+				llmConfigs = append(llmConfigs, config.Llms[i])
+				found = true
+			}
+		}
+
+		if !found {
+			utils.LogF(fmt.Sprintf("Can't test %s as its' configuration can't be found. Run `setup --llm %s` to add it", llm, llm))
+		}
+	}
+
+	if len(c.Args) == 0 {
+		llmConfigs = config.Llms
+	}
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Suffix = " Validating API keys"
+	s.Start()
+	checks := helpers.CheckLlmKeysConcurrently(llmConfigs)
+	s.Stop()
+
+	rejected := false
+
+	for _, check := range checks {
+		source := ""
+		if check.Source != "" {
+			source = fmt.Sprintf(" (from %s)", check.Source)
+		}
+
+		switch check.Status {
+		case helpers.KeyValid:
+			fmt.Printf("✅ %s - %s%s\n", check.Llm, check.Status, source)
+		case helpers.KeyInvalid, helpers.KeyExpired:
+			rejected = true
+			fmt.Printf("❌ %s - %s%s: %s\n", check.Llm, styles.BoldPinkTextStyle.Render(string(check.Status)), source, check.Message)
+		default:
+			fmt.Printf("⚠️ %s - %s%s: %s\n", check.Llm, check.Status, source, check.Message)
+		}
+	}
+
+	if rejected {
+		os.Exit(1)
+	}
+}
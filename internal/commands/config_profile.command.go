@@ -0,0 +1,294 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type ConfigProfileCmd struct {
+	BaseCmd
+}
+type ConfigProfileCreateCmd struct {
+	BaseCmd
+}
+type ConfigProfileUseCmd struct {
+	BaseCmd
+}
+type ConfigProfileListCmd struct {
+	BaseCmd
+}
+type ConfigProfileSetAuditCmd struct {
+	BaseCmd
+}
+
+func (c ConfigProfileCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "profile",
+		Short:   "Manage named profiles, each with their own LLMs, default LLM and audit defaults",
+		Example: "insightly config profile [command]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+
+			return nil
+		},
+	}
+
+	configProfileCreateCmd := ConfigProfileCreateCmd{}
+	configProfileUseCmd := ConfigProfileUseCmd{}
+	configProfileListCmd := ConfigProfileListCmd{}
+	configProfileSetAuditCmd := ConfigProfileSetAuditCmd{}
+
+	cmd.AddCommand(configProfileCreateCmd.New())
+	cmd.AddCommand(configProfileUseCmd.New())
+	cmd.AddCommand(configProfileListCmd.New())
+	cmd.AddCommand(configProfileSetAuditCmd.New())
+
+	return cmd
+}
+
+// readConfigForProfiles reads the config file without applying the selected profile, as the profile
+// commands work on all of the profiles
+func readConfigForProfiles() helpers.ConfigFile {
+	config, _, err := helpers.ReadRawConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.LogF(err.Error())
+	}
+
+	return config
+}
+
+func (c ConfigProfileCreateCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "create",
+		Short:   "Create a new profile",
+		Example: "insightly config profile create <name> [--use]",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("use", "", false, "Use the new profile by default")
+
+	return cmd
+}
+
+func (c ConfigProfileCreateCmd) Handler() {
+	name := c.Args[0]
+	use, _ := c.Cmd.Flags().GetBool("use")
+
+	if name == helpers.DefaultProfile {
+		utils.LogF(fmt.Sprintf("%s profile always exists", helpers.DefaultProfile))
+	}
+
+	if err := helpers.ValidateProfileName(name); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	config := readConfigForProfiles()
+
+	if _, ok := config.Profiles[name]; ok {
+		utils.LogF(fmt.Sprintf("Profile %s already exists", name))
+	}
+
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]helpers.Profile)
+	}
+
+	config.Profiles[name] = helpers.Profile{}
+
+	if use {
+		config.Profile = name
+	}
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("Successfully created %s profile. Run `setup --profile %s` to setup its LLM configuration\n", styles.BoldBlueTextStyle.Render(name), name)
+}
+
+func (c ConfigProfileUseCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "use",
+		Short:   "Change the profile which is used when `--profile` isn't passed",
+		Example: "insightly config profile use <name>",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c ConfigProfileUseCmd) Handler() {
+	name := c.Args[0]
+
+	config := readConfigForProfiles()
+
+	if name == helpers.DefaultProfile {
+		config.Profile = ""
+	} else {
+		if _, ok := config.Profiles[name]; !ok {
+			utils.LogF(fmt.Sprintf("Profile %s doesn't exist. Run `config profile create %s` to create it", name, name))
+		}
+
+		config.Profile = name
+	}
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("You're now using %s profile\n", styles.BoldBlueTextStyle.Render(name))
+
+	if os.Getenv("INSIGHTLY_PROFILE") != "" {
+		fmt.Println("⚠️ INSIGHTLY_PROFILE is set, which takes precedence over the profile selected via `config profile use`")
+	}
+}
+
+func (c ConfigProfileListCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the profiles",
+		Example: "insightly config profile list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c ConfigProfileListCmd) Handler() {
+	config := readConfigForProfiles()
+	current := helpers.GetProfileName(config)
+
+	for _, name := range helpers.ListProfiles(config) {
+		profile, ok := config.Profiles[name]
+		if name == helpers.DefaultProfile {
+			profile, ok = helpers.Profile{Default: config.Default, Llms: config.Llms}, true
+		}
+
+		if !ok {
+			continue
+		}
+
+		var llms []string
+		for i := range profile.Llms {
+			llms = append(llms, string(profile.Llms[i].Name))
+		}
+
+		details := "no LLMs"
+		if len(llms) != 0 {
+			details = fmt.Sprintf("default %s, LLMs %s", profile.Default, strings.Join(llms, ", "))
+		}
+
+		if name == current {
+			fmt.Printf("* %s (%s)\n", styles.BoldBlueTextStyle.Render(name), details)
+		} else {
+			fmt.Printf("  %s (%s)\n", name, details)
+		}
+	}
+}
+
+func (c ConfigProfileSetAuditCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "set-audit",
+		Short:   "Change the audit defaults of the profile which are used by `gen-ux`",
+		Example: "insightly config profile set-audit --auditors pa11y --standard WCAG2AA --use-ai [--profile <name>]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSlice("auditors", nil, "Auditors which are run, lighthouse and/or pa11y")
+	cmd.Flags().String("standard", "", "Accessibility standard which pa11y audits against, e.g. WCAG2AA")
+	cmd.Flags().BoolP("use-ai", "", false, "Generate a summary using LLMs")
+	cmd.Flags().BoolP("save-report", "", false, "Save parsed report in JSON format")
+	cmd.Flags().String("persona", "", "Persona which is prepended to the prompt")
+	cmd.Flags().BoolP("reset", "", false, "Remove all of the audit defaults")
+
+	return cmd
+}
+
+func (c ConfigProfileSetAuditCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	config, err := helpers.ReadConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("It seems like you're trying to run `config profile set-audit` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	if reset, _ := flags.GetBool("reset"); reset {
+		config.Audit = helpers.AuditDefaults{}
+	}
+
+	if flags.Changed("auditors") {
+		auditors, _ := flags.GetStringSlice("auditors")
+
+		for _, auditor := range auditors {
+			if !utils.OneOfThem(auditor, helpers.ValidAuditors) {
+				utils.LogF(fmt.Sprintf("%s isn't a valid auditor. Valid auditors are %s", auditor, strings.Join(helpers.ValidAuditors, ", ")))
+			}
+		}
+
+		config.Audit.Auditors = auditors
+	}
+
+	if flags.Changed("standard") {
+		config.Audit.Standard, _ = flags.GetString("standard")
+	}
+
+	if flags.Changed("use-ai") {
+		config.Audit.UseAi, _ = flags.GetBool("use-ai")
+	}
+
+	if flags.Changed("save-report") {
+		config.Audit.SaveReport, _ = flags.GetBool("save-report")
+	}
+
+	if flags.Changed("persona") {
+		config.Audit.Persona, _ = flags.GetString("persona")
+	}
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("Successfully updated the audit defaults of %s profile\n", styles.BoldBlueTextStyle.Render(helpers.GetProfileName(config)))
+}
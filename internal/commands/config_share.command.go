@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type ConfigExportCmd struct {
+	BaseCmd
+}
+type ConfigImportCmd struct {
+	BaseCmd
+}
+
+var validConflictStrategies = []string{"ask", "keep", "replace"}
+
+func (c ConfigExportCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export the config, with all of its profiles, so that it can be shared with your team",
+		Example: "insightly config export [file] [--keys none|encrypted|plaintext]",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("keys", string(helpers.ExportWithoutKeys), "How the API keys are exported: none, encrypted (using a passphrase) or plaintext")
+
+	return cmd
+}
+
+func (c ConfigExportCmd) Handler() {
+	keys, _ := c.Cmd.Flags().GetString("keys")
+
+	if !utils.OneOfThem(keys, helpers.ValidExportKeyModes) {
+		utils.LogF(fmt.Sprintf("%s isn't a valid value for `--keys`. Valid values are %s", keys, strings.Join(helpers.ValidExportKeyModes, ", ")))
+	}
+
+	config, _, err := helpers.ReadRawConfigFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			utils.LogF("It seems like you're trying to run `config export` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		}
+
+		utils.LogF(err.Error())
+	}
+
+	data, err := helpers.ExportConfig(config, helpers.ExportKeyMode(keys))
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if len(c.Args) == 0 || c.Args[0] == "-" {
+		fmt.Println(strings.TrimSpace(string(data)))
+		return
+	}
+
+	if err := os.WriteFile(c.Args[0], append(data, '\n'), 0600); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("Successfully exported the config to %s\n", styles.BoldBlueTextStyle.Render(c.Args[0]))
+
+	if helpers.ExportKeyMode(keys) == helpers.ExportPlaintextKeys {
+		fmt.Println("⚠️ The exported config holds your API keys in plaintext, use `--keys encrypted` for sharing it")
+	}
+}
+
+func (c ConfigImportCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Merge an exported config into your config",
+		Example: "insightly config import <file> [--on-conflict ask|keep|replace]\n  cat team.json | insightly config import - --on-conflict keep",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("on-conflict", "ask", "What to do when a field is set differently in both of the configs: ask, keep or replace")
+
+	return cmd
+}
+
+func (c ConfigImportCmd) Handler() {
+	onConflict, _ := c.Cmd.Flags().GetString("on-conflict")
+	assumeYes, _ := c.Cmd.Flags().GetBool("yes")
+
+	if !utils.OneOfThem(onConflict, validConflictStrategies) {
+		utils.LogF(fmt.Sprintf("%s isn't a valid value for `--on-conflict`. Valid values are %s", onConflict, strings.Join(validConflictStrategies, ", ")))
+	}
+
+	var data []byte
+	var err error
+
+	if c.Args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(c.Args[0])
+	}
+
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	imported, err := helpers.ReadExportedConfig(data)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	config, _, err := helpers.ReadRawConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		utils.LogF(err.Error())
+	}
+
+	merged, changes, err := helpers.MergeConfig(config, imported, func(conflict helpers.ConfigConflict) (bool, error) {
+		field := conflict.Field
+		if conflict.Profile != "" {
+			field = fmt.Sprintf("%s of %s profile", conflict.Field, conflict.Profile)
+		}
+
+		// api_key_cmd runs via the shell whenever the key is needed, and disabling redaction sends the
+		// sensitive values to the LLMs, so they are never accepted without seeing them
+		if conflict.Command != "" || conflict.Warning != "" {
+			if onConflict == "keep" {
+				return false, nil
+			}
+
+			if !helpers.IsInteractive() {
+				if conflict.Command != "" {
+					fmt.Printf("⚠️ Skipped the imported api_key_cmd for the %s, commands can only be imported after confirming them interactively\n", field)
+				} else {
+					fmt.Printf("⚠️ Kept %s as %s, changing it to %s can only be confirmed interactively\n", field, conflict.Current, conflict.Import)
+				}
+
+				return false, nil
+			}
+
+			if conflict.Command != "" {
+				return helpers.Confirm(fmt.Sprintf("The imported config reads the %s by running the command below on this machine. Only accept it if you trust the exported config\n\n    %s\n\nRun this command for reading the key?", field, conflict.Command), false)
+			}
+
+			return helpers.Confirm(fmt.Sprintf("The imported config sets %s to %s, so %s. Only accept it if you trust the exported config\n\nReplace %s with %s?", field, conflict.Import, conflict.Warning, conflict.Current, conflict.Import), false)
+		}
+
+		switch onConflict {
+		case "keep":
+			return false, nil
+		case "replace":
+			return true, nil
+		}
+
+		return helpers.Confirm(fmt.Sprintf("%s is %s, replace it with %s?", field, conflict.Current, conflict.Import), assumeYes)
+	})
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("Your config is already up to date with the imported config")
+		return
+	}
+
+	if err := helpers.WriteToConfigFile(merged); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	for _, change := range changes {
+		fmt.Printf("✅ %s\n", change)
+	}
+
+	for _, problem := range helpers.ValidateConfig(merged) {
+		if problem.Warning {
+			fmt.Printf("⚠️ %s\n", problem.Message)
+		}
+	}
+}
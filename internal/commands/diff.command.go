@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type DiffCmd struct {
+	BaseCmd
+}
+
+var validDiffFormats = []string{"text", "markdown", "json"}
+
+func (c DiffCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Show the metric deltas and the new, fixed and persisting issues between two runs",
+		Example: "insightly diff <run-a> <run-b> [--format text|markdown|json] [--output <file>]",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	addDiffFlags(cmd)
+
+	return cmd
+}
+
+func addDiffFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", "text", "Output format, one of text, markdown or json")
+	cmd.Flags().StringP("output", "o", "", "Write the comparison to the given file instead of printing it")
+	cmd.Flags().Float64("tolerance", helpers.DefaultRegressionTolerance, "How much worse a metric can get before it is flagged as a regression, in percent")
+	cmd.Flags().BoolP("fail-on-regression", "", false, "Exit with status 1 if there are any new issues or regressed metrics")
+}
+
+type diffOptions struct {
+	format           string
+	output           string
+	tolerance        float64
+	failOnRegression bool
+}
+
+// readDiffOptions reads the flags added by addDiffFlags. the tolerance falls back to the one of the
+// project config, if there is one
+func readDiffOptions(cmd *cobra.Command) diffOptions {
+	opts := diffOptions{}
+	opts.format, _ = cmd.Flags().GetString("format")
+	opts.output, _ = cmd.Flags().GetString("output")
+	opts.tolerance, _ = cmd.Flags().GetFloat64("tolerance")
+	opts.failOnRegression, _ = cmd.Flags().GetBool("fail-on-regression")
+
+	if !utils.OneOfThem(opts.format, validDiffFormats) {
+		utils.LogF(fmt.Sprintf("❌ Invalid format %s, valid formats are %s", opts.format, strings.Join(validDiffFormats, ", ")))
+	}
+
+	if !cmd.Flags().Changed("tolerance") {
+		projectConfig, _, err := helpers.FindProjectConfig()
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		if projectConfig.Thresholds.RegressionTolerance != nil {
+			opts.tolerance = *projectConfig.Thresholds.RegressionTolerance
+		}
+	}
+
+	if opts.tolerance < 0 {
+		utils.LogF("❌ Tolerance can't be negative")
+	}
+
+	return opts
+}
+
+func (c DiffCmd) Handler() {
+	opts := readDiffOptions(c.Cmd)
+
+	base, _, err := helpers.ReadHistoryEntry(c.Args[0])
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	head, _, err := helpers.ReadHistoryEntry(c.Args[1])
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if base.Tool != head.Tool {
+		utils.LogF(fmt.Sprintf("❌ Runs of different auditors can't be compared, %s is a %s run whereas %s is a %s run", base.Id, base.Tool, head.Id, head.Tool))
+	}
+
+	diff := helpers.DiffRuns(base, head, opts.tolerance)
+
+	outputRunDiff(diff, base.Id, head.Id, opts)
+}
+
+// outputRunDiff prints the diff or writes it to `--output`, and exits with status 1 on regressions if
+// `--fail-on-regression` is passed
+func outputRunDiff(diff helpers.RunDiff, baseLabel string, headLabel string, opts diffOptions) {
+	var output string
+
+	switch opts.format {
+	case "markdown":
+		output = renderRunDiffMarkdown(diff, baseLabel, headLabel)
+	case "json":
+		output = encodeReport(diff)
+	default:
+		output = renderRunDiffText(diff, baseLabel, headLabel, opts.output == "")
+	}
+
+	if opts.output != "" {
+		if err := os.WriteFile(opts.output, []byte(output), 0644); err != nil {
+			utils.LogF(err.Error())
+		}
+
+		fmt.Printf("Saved the comparison to `%s`\n", opts.output)
+	} else {
+		fmt.Print(output)
+	}
+
+	if opts.failOnRegression && diff.Regressed() {
+		os.Exit(1)
+	}
+}
+
+func renderRunDiffText(diff helpers.RunDiff, baseLabel string, headLabel string, colored bool) string {
+	highlight := func(text string) string {
+		if colored {
+			return styles.BoldPinkTextStyle.Render(text)
+		}
+
+		return text
+	}
+
+	var builder strings.Builder
+
+	describe := func(label string, entry helpers.HistoryEntry) string {
+		if label == entry.Url {
+			return label
+		}
+
+		return fmt.Sprintf("%s (%s)", label, entry.Url)
+	}
+
+	fmt.Fprintf(&builder, "Comparing %s against %s\n", describe(headLabel, diff.Head), describe(baseLabel, diff.Base))
+
+	if len(diff.Metrics) != 0 {
+		builder.WriteString("\n")
+
+		rows := [][]string{{"METRIC", baseLabel, headLabel, "CHANGE"}}
+
+		for _, delta := range diff.Metrics {
+			change := formatTrendChange(delta.Change)
+			if delta.Regression {
+				change = highlight(change + " regression")
+			}
+
+			rows = append(rows, []string{delta.Label, formatTrendValue(delta.Metric, delta.Base), formatTrendValue(delta.Metric, delta.Head), change})
+		}
+
+		builder.WriteString(formatTrendRows(rows))
+	}
+
+	fmt.Fprintf(&builder, "\nIssues: %d new, %d fixed, %d persisting\n", len(diff.New), len(diff.Fixed), len(diff.Persisting))
+
+	sections := []struct {
+		title  string
+		issues []helpers.Issue
+	}{
+		{"New issues", diff.New},
+		{"Fixed issues", diff.Fixed},
+		{"Persisting issues", diff.Persisting},
+	}
+
+	for _, section := range sections {
+		if len(section.issues) == 0 {
+			continue
+		}
+
+		title := section.title + ":"
+		if section.title == "New issues" {
+			title = highlight(title)
+		}
+
+		fmt.Fprintf(&builder, "\n%s\n", title)
+
+		for _, issue := range section.issues {
+			age := ""
+			if section.title == "Persisting issues" && issue.FirstSeen != nil {
+				age = fmt.Sprintf(" (open since %s)", issue.FirstSeen.Local().Format("2006-01-02"))
+			}
+
+			fmt.Fprintf(&builder, ">> [%s] %s - %s%s\n", issue.Severity, issue.Rule, issue.Message, age)
+
+			if issue.Selector != "" {
+				fmt.Fprintf(&builder, "   %s\n", issue.Selector)
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+func renderRunDiffMarkdown(diff helpers.RunDiff, baseLabel string, headLabel string) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "# Comparing %s against %s\n\n", headLabel, baseLabel)
+
+	builder.WriteString("| | base | head |\n")
+	builder.WriteString("| --- | --- | --- |\n")
+	fmt.Fprintf(&builder, "| run | %s | %s |\n", diff.Base.Id, diff.Head.Id)
+	fmt.Fprintf(&builder, "| url | %s | %s |\n", diff.Base.Url, diff.Head.Url)
+	fmt.Fprintf(&builder, "| auditor | %s | %s |\n", diff.Base.Tool, diff.Head.Tool)
+	fmt.Fprintf(&builder, "| date | %s | %s |\n", diff.Base.CreatedAt.Local().Format("2006-01-02 15:04"), diff.Head.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	if len(diff.Metrics) != 0 {
+		builder.WriteString("\n## Metrics\n\n")
+		builder.WriteString("| metric | base | head | change |\n")
+		builder.WriteString("| --- | --- | --- | --- |\n")
+
+		for _, delta := range diff.Metrics {
+			change := formatTrendChange(delta.Change)
+			if delta.Regression {
+				change = fmt.Sprintf("**%s regression**", change)
+			}
+
+			fmt.Fprintf(&builder, "| %s | %s | %s | %s |\n", delta.Label, formatTrendValue(delta.Metric, delta.Base), formatTrendValue(delta.Metric, delta.Head), change)
+		}
+	}
+
+	fmt.Fprintf(&builder, "\n## Issues\n\n**%d new**, %d fixed, %d persisting\n", len(diff.New), len(diff.Fixed), len(diff.Persisting))
+
+	sections := []struct {
+		title  string
+		issues []helpers.Issue
+	}{
+		{"New", diff.New},
+		{"Fixed", diff.Fixed},
+		{"Persisting", diff.Persisting},
+	}
+
+	for _, section := range sections {
+		if len(section.issues) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&builder, "\n### %s\n\n", section.title)
+
+		for _, issue := range section.issues {
+			fmt.Fprintf(&builder, "- **%s** `%s`: %s", issue.Severity, issue.Rule, strings.ReplaceAll(issue.Message, "\n", " "))
+
+			if issue.Selector != "" {
+				fmt.Fprintf(&builder, " (`%s`)", issue.Selector)
+			}
+
+			if section.title == "Persisting" && issue.FirstSeen != nil {
+				fmt.Fprintf(&builder, ", open since %s", issue.FirstSeen.Local().Format("2006-01-02"))
+			}
+
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type ExportCmd struct {
+	BaseCmd
+}
+
+func (c ExportCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export a saved run as JSON, HTML, markdown, CSV or SARIF",
+		Example: "insightly export [history-id] [--format json|html|markdown|csv|sarif] [--output <file>]",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("format", "markdown", "Output format, one of json, html, markdown, csv or sarif")
+	cmd.Flags().StringP("output", "o", "", "Write the report to the given file instead of printing it. Unless `--format` is passed, the format is inferred from its extension")
+
+	return cmd
+}
+
+func (c ExportCmd) Handler() {
+	format, _ := c.Cmd.Flags().GetString("format")
+	output, _ := c.Cmd.Flags().GetString("output")
+
+	if output != "" && !c.Cmd.Flags().Changed("format") {
+		var err error
+
+		format, output, err = helpers.ParseOutput(output)
+		if err != nil {
+			utils.LogF(fmt.Sprintf("❌ %s", err.Error()))
+		}
+	}
+
+	if !utils.OneOfThem(format, helpers.ValidOutputFormats) {
+		utils.LogF(fmt.Sprintf("❌ Invalid format %s, valid formats are %s", format, strings.Join(helpers.ValidOutputFormats, ", ")))
+	}
+
+	historyId := "latest"
+	if len(c.Args) == 1 {
+		historyId = c.Args[0]
+	}
+
+	entry, summary, err := helpers.ReadHistoryEntry(historyId)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	report, err := helpers.RenderReport(format, []helpers.ReportRun{{Entry: entry, Summary: summary}})
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	output = helpers.ExpandOutputPath(output, entry)
+
+	if output != "" && output != "-" {
+		if dir := filepath.Dir(output); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				utils.LogF(err.Error())
+			}
+		}
+
+		if err := os.WriteFile(output, report, 0644); err != nil {
+			utils.LogF(err.Error())
+		}
+
+		fmt.Printf("Saved %s as %s to `%s`\n", entry.Id, format, output)
+	} else {
+		if !bytes.HasSuffix(report, []byte("\n")) {
+			report = append(report, '\n')
+		}
+
+		os.Stdout.Write(report)
+	}
+}
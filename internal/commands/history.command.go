@@ -0,0 +1,491 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type HistoryCmd struct {
+	BaseCmd
+}
+type HistoryListCmd struct {
+	BaseCmd
+}
+type HistoryShowCmd struct {
+	BaseCmd
+}
+type HistorySearchCmd struct {
+	BaseCmd
+}
+type HistoryRmCmd struct {
+	BaseCmd
+}
+type HistoryPruneCmd struct {
+	BaseCmd
+}
+
+func (c HistoryCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "List, view, search and delete previous runs",
+		Example: "insightly history [command]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+
+			return nil
+		},
+	}
+
+	historyListCmd := HistoryListCmd{}
+	historyShowCmd := HistoryShowCmd{}
+	historySearchCmd := HistorySearchCmd{}
+	historyRmCmd := HistoryRmCmd{}
+	historyPruneCmd := HistoryPruneCmd{}
+
+	cmd.AddCommand(historyListCmd.New())
+	cmd.AddCommand(historyShowCmd.New())
+	cmd.AddCommand(historySearchCmd.New())
+	cmd.AddCommand(historyRmCmd.New())
+	cmd.AddCommand(historyPruneCmd.New())
+
+	return cmd
+}
+
+// printHistoryEntries prints the entries as a table, or as JSON if asJson is set
+func printHistoryEntries(entries []helpers.HistoryIndexEntry, asJson bool) {
+	if asJson {
+		if entries == nil {
+			entries = []helpers.HistoryIndexEntry{}
+		}
+
+		fmt.Print(encodeReport(entries))
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No history entries found")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tDATE\tTOOL\tURL\tPERFORMANCE\tISSUES\tLLM")
+
+	for _, entry := range entries {
+		score := "-"
+		if entry.Score != nil {
+			score = fmt.Sprintf("%.2f", *entry.Score)
+		}
+
+		llm := "-"
+		if entry.HasSummary {
+			llm = string(entry.Llm)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", entry.Id, entry.CreatedAt.Local().Format("2006-01-02 15:04"), entry.Tool, entry.Url, score, entry.Issues, llm)
+	}
+
+	writer.Flush()
+}
+
+func (c HistoryListCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the previous runs, the most recent one first",
+		Example: "insightly history list [--url <url>] [--tool lighthouse|pa11y] [--limit 20]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("url", "", "Only list the runs whose URL contains the given value")
+	cmd.Flags().String("tool", "", "Only list the runs of the given auditor")
+	cmd.Flags().Int("limit", 20, "Maximum number of runs which are listed, 0 lists all of them")
+	cmd.Flags().BoolP("json", "", false, "Print the runs as JSON")
+
+	return cmd
+}
+
+func (c HistoryListCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	url, _ := flags.GetString("url")
+	tool, _ := flags.GetString("tool")
+	limit, _ := flags.GetInt("limit")
+	asJson, _ := flags.GetBool("json")
+
+	entries, err := helpers.ListHistoryEntries()
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	var filtered []helpers.HistoryIndexEntry
+
+	for _, entry := range entries {
+		if url != "" && !strings.Contains(entry.Url, url) {
+			continue
+		}
+
+		if tool != "" && entry.Tool != tool {
+			continue
+		}
+
+		filtered = append(filtered, entry)
+	}
+
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[:limit]
+	}
+
+	printHistoryEntries(filtered, asJson)
+}
+
+func (c HistoryShowCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "show",
+		Short:   "Show a previous run along with its issues and AI summary",
+		Example: "insightly history show [id|latest] [--issues] [--report] [--json]",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("issues", "", false, "List each of the issues")
+	cmd.Flags().BoolP("report", "", false, "Print the raw report of the auditor instead")
+	cmd.Flags().BoolP("json", "", false, "Print the run as JSON")
+
+	return cmd
+}
+
+func (c HistoryShowCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	showIssues, _ := flags.GetBool("issues")
+	showReport, _ := flags.GetBool("report")
+	asJson, _ := flags.GetBool("json")
+
+	id := "latest"
+	if len(c.Args) == 1 {
+		id = c.Args[0]
+	}
+
+	entry, summary, err := helpers.ReadHistoryEntry(id)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if showReport {
+		if entry.Report == "" {
+			utils.LogF(fmt.Sprintf("History entry %s doesn't have a report", entry.Id))
+		}
+
+		fmt.Print(entry.Report)
+		return
+	}
+
+	if asJson {
+		fmt.Print(encodeReport(struct {
+			helpers.HistoryEntry
+			Summary string `json:"summary,omitempty"`
+		}{entry, summary}))
+		return
+	}
+
+	fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("ID:"), entry.Id)
+	fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("URL:"), entry.Url)
+	fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("Tool:"), entry.Tool)
+	fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("Date:"), entry.CreatedAt.Local().Format(time.RFC1123))
+
+	if entry.Llm != "" {
+		llm := string(entry.Llm)
+		if len(entry.Compared) != 0 {
+			var compared []string
+			for _, llm := range entry.Compared {
+				compared = append(compared, string(llm))
+			}
+
+			llm = fmt.Sprintf("%s (compared %s)", llm, strings.Join(compared, ", "))
+		}
+
+		fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("LLM:"), llm)
+	}
+
+	for _, name := range helpers.LighthouseMetrics {
+		if value, ok := entry.Metrics[name]; ok {
+			fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render(strings.ReplaceAll(name, "_", " ")+":"), value)
+		}
+	}
+
+	counts := helpers.CountIssuesBySeverity(entry.Issues)
+
+	var severities []string
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+
+	sort.Strings(severities)
+
+	var breakdown []string
+	for _, severity := range severities {
+		breakdown = append(breakdown, fmt.Sprintf("%d %s", counts[severity], severity))
+	}
+
+	issues := fmt.Sprintf("%d", len(entry.Issues))
+	if len(breakdown) != 0 {
+		issues = fmt.Sprintf("%s (%s)", issues, strings.Join(breakdown, ", "))
+	}
+
+	fmt.Printf("%s %s\n", styles.BoldBlueTextStyle.Render("Issues:"), issues)
+
+	if entry.Previous != "" {
+		statuses := map[string]int{}
+		for _, issue := range entry.Issues {
+			statuses[issue.Status]++
+		}
+
+		fmt.Printf("%s %d new, %d persisting and %d fixed since %s\n", styles.BoldBlueTextStyle.Render("Compared to the previous run:"), statuses[helpers.IssueNew], statuses[helpers.IssuePersisting], len(entry.Fixed), entry.Previous)
+	}
+
+	if showIssues {
+		fmt.Println()
+
+		for _, issue := range entry.Issues {
+			fmt.Printf(">> [%s] %s - %s%s%s\n", issue.Severity, issue.Rule, issue.Message, describeIssueLifecycle(issue, entry.CreatedAt), describeIssueTriage(issue))
+
+			if issue.Selector != "" {
+				fmt.Printf("   %s\n", issue.Selector)
+			}
+		}
+
+		if len(entry.Fixed) != 0 {
+			fmt.Printf("\nFixed since the previous run:\n")
+
+			for _, issue := range entry.Fixed {
+				fmt.Printf(">> [%s] %s - %s%s\n", issue.Severity, issue.Rule, issue.Message, describeIssueLifecycle(issue, entry.CreatedAt))
+
+				if issue.Selector != "" {
+					fmt.Printf("   %s\n", issue.Selector)
+				}
+			}
+		}
+	}
+
+	if summary != "" {
+		fmt.Printf("\n%s\n", strings.TrimSpace(summary))
+	} else if !showIssues {
+		fmt.Printf("\nThis run doesn't have an AI summary. Run `insightly history show %s --issues` to list the issues\n", entry.Id)
+	}
+}
+
+// describeIssueLifecycle describes whether the issue is new, for how long it has been open or how long it
+// took to fix it. issues saved before their lifecycle was tracked aren't described
+func describeIssueLifecycle(issue helpers.Issue, now time.Time) string {
+	age := helpers.FormatIssueAge(helpers.IssueAge(issue, now))
+
+	switch issue.Status {
+	case helpers.IssueNew:
+		return " (new)"
+	case helpers.IssuePersisting:
+		return fmt.Sprintf(" (open for %s, since %s)", age, issue.FirstSeen.Local().Format("2006-01-02"))
+	case helpers.IssueFixed:
+		return fmt.Sprintf(" (fixed after %s)", age)
+	default:
+		return ""
+	}
+}
+
+func (c HistorySearchCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "search",
+		Short:   "Search the previous runs by URL, LLM, issues or AI summary",
+		Example: "insightly history search <query>",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolP("json", "", false, "Print the matching runs as JSON")
+
+	return cmd
+}
+
+func (c HistorySearchCmd) Handler() {
+	asJson, _ := c.Cmd.Flags().GetBool("json")
+
+	entries, err := helpers.SearchHistory(strings.Join(c.Args, " "))
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	printHistoryEntries(entries, asJson)
+}
+
+func (c HistoryRmCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm",
+		Short:   "Delete previous runs along with their reports, summaries and chat transcripts",
+		Example: "insightly history rm <id>...",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (c HistoryRmCmd) Handler() {
+	assumeYes, _ := c.Cmd.Flags().GetBool("yes")
+
+	var ids []string
+
+	for _, arg := range c.Args {
+		id, err := helpers.ResolveHistoryId(arg)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		ids = append(ids, id)
+	}
+
+	confirmed, err := helpers.Confirm(fmt.Sprintf("delete %s from history?", strings.Join(ids, ", ")), assumeYes)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if !confirmed {
+		return
+	}
+
+	for _, id := range ids {
+		if err := helpers.DeleteHistoryEntry(id); err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	fmt.Printf("Successfully deleted %d run(s) from history\n", len(ids))
+}
+
+func (c HistoryPruneCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Delete old runs from history",
+		Example: "insightly history prune [--older-than 30d] [--keep 50]",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("older-than", "", "Delete the runs which are older than the given duration, e.g. 30d, 2w or 12h")
+	cmd.Flags().Int("keep", -1, "Only keep the given number of most recent runs")
+
+	return cmd
+}
+
+func (c HistoryPruneCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	olderThan, _ := flags.GetString("older-than")
+	keep, _ := flags.GetInt("keep")
+	assumeYes, _ := flags.GetBool("yes")
+
+	if olderThan == "" && keep < 0 {
+		utils.LogF("Pass `--older-than` and/or `--keep`, e.g. `history prune --older-than 30d`")
+	}
+
+	var age time.Duration
+
+	if olderThan != "" {
+		var err error
+
+		age, err = utils.ParseDuration(olderThan)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	entries, err := helpers.ListHistoryEntries()
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	pruned := helpers.SelectHistoryEntriesToPrune(entries, age, keep)
+
+	if len(pruned) == 0 {
+		fmt.Println("Nothing to prune")
+		return
+	}
+
+	confirmed, err := helpers.Confirm(fmt.Sprintf("delete %d of %d run(s) from history?", len(pruned), len(entries)), assumeYes)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	if !confirmed {
+		return
+	}
+
+	for _, entry := range pruned {
+		if err := helpers.DeleteHistoryEntry(entry.Id); err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	fmt.Printf("Successfully deleted %d run(s) from history\n", len(pruned))
+}
+
+// describeIssueTriage describes what was decided for the issue during triage and who owns it, if anything
+func describeIssueTriage(issue helpers.Issue) string {
+	var parts []string
+
+	if issue.Triage != "" {
+		parts = append(parts, issue.Triage)
+	}
+
+	if issue.Owner != "" {
+		parts = append(parts, "owner: "+issue.Owner)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" [%s]", strings.Join(parts, ", "))
+}
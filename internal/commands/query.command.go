@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+type QueryCmd struct {
+	BaseCmd
+}
+
+func (c QueryCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "query",
+		Short:   "Run SQL queries or canned reports against the database of previous runs",
+		Example: "insightly query \"SELECT rule, COUNT(*) FROM issues GROUP BY rule\" | insightly query --report top-rules",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	var reports []string
+	for _, query := range helpers.CannedQueries {
+		reports = append(reports, query.Name)
+	}
+
+	cmd.Flags().String("report", "", fmt.Sprintf("Run one of the canned reports: %s", strings.Join(reports, ", ")))
+	cmd.Flags().BoolP("list-reports", "", false, "List the canned reports along with their queries")
+	cmd.Flags().BoolP("json", "", false, "Print the rows as JSON")
+
+	return cmd
+}
+
+func (c QueryCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	report, _ := flags.GetString("report")
+	listReports, _ := flags.GetBool("list-reports")
+	asJson, _ := flags.GetBool("json")
+
+	if listReports {
+		for _, query := range helpers.CannedQueries {
+			fmt.Printf("%s - %s\n\n%s\n\n", query.Name, query.Description, query.Query)
+		}
+
+		return
+	}
+
+	var query string
+
+	switch {
+	case report != "" && len(c.Args) == 1:
+		utils.LogF("❌ Pass either a query or `--report`, not both")
+	case report != "":
+		cannedQuery, ok := helpers.FindCannedQuery(report)
+		if !ok {
+			utils.LogF(fmt.Sprintf("❌ Unknown report %s. Run `insightly query --list-reports` to list the reports", report))
+		}
+
+		query = cannedQuery.Query
+	case len(c.Args) == 1:
+		query = c.Args[0]
+	default:
+		utils.LogF("❌ Pass a query, e.g. `insightly query \"SELECT * FROM runs\"`, or `--report <name>`")
+	}
+
+	result, err := helpers.QueryStore(query)
+	if err != nil {
+		utils.LogF(fmt.Sprintf("❌ %s", err.Error()))
+	}
+
+	if asJson {
+		rows := []map[string]any{}
+
+		for _, row := range result.Rows {
+			object := make(map[string]any)
+
+			for i, column := range result.Columns {
+				object[column] = row[i]
+			}
+
+			rows = append(rows, object)
+		}
+
+		fmt.Print(encodeReport(rows))
+		return
+	}
+
+	if len(result.Rows) == 0 {
+		fmt.Println("No rows found")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(result.Columns, "\t")))
+
+	for _, row := range result.Rows {
+		values := make([]string, len(row))
+
+		for i, value := range row {
+			switch value := value.(type) {
+			case nil:
+				values[i] = "-"
+			case float64:
+				values[i] = strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+			default:
+				values[i] = strings.ReplaceAll(fmt.Sprint(value), "\n", " ")
+			}
+		}
+
+		fmt.Fprintln(writer, strings.Join(values, "\t"))
+	}
+
+	writer.Flush()
+}
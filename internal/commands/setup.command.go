@@ -3,9 +3,13 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/0xmukesh/insightly/internal/helpers"
 	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/briandowns/spinner"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
 )
@@ -18,7 +22,7 @@ func (c SetupCmd) New() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "setup",
 		Short:   "Setup your API keys for different LLMs and store it locally",
-		Example: "insightly setup",
+		Example: "insightly setup\n  echo $GEMINI_API_KEY | insightly setup --llm gemini --key-stdin --default",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Cmd = cmd
 			c.Args = args
@@ -31,15 +35,57 @@ func (c SetupCmd) New() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().String("llm", "", "Setup the given LLM without opening the interactive form")
+	cmd.Flags().BoolP("key-stdin", "", false, "Read the API key of the LLM passed via `--llm` from stdin")
+	cmd.Flags().String("key-cmd", "", "Command whose output is used as the API key of the LLM passed via `--llm`")
+	cmd.Flags().BoolP("default", "", false, "Set the LLM passed via `--llm` as your default LLM")
+	cmd.Flags().BoolP("skip-validation", "", false, "Don't validate the API keys against the LLMs' providers")
+
 	return cmd
 }
 
 func (c SetupCmd) Handler() error {
+	cmd := c.Cmd
+
+	llm, _ := cmd.Flags().GetString("llm")
+	assumeYes, _ := cmd.Flags().GetBool("yes")
+
+	if llm != "" {
+		return c.nonInteractiveHandler()
+	}
+
+	if !helpers.IsInteractive() {
+		return helpers.ErrNotInteractive
+	}
+
+	// only the LLMs of the selected profile are replaced, the rest of the config file is kept
+	configFile, err := helpers.ReadConfigFile()
+	if err != nil {
+		var syntaxErr *helpers.ConfigSyntaxError
+
+		if !errors.Is(err, os.ErrNotExist) && !errors.As(err, &syntaxErr) {
+			return err
+		}
+
+		configFile = helpers.ConfigFile{}
+	}
+
+	if len(configFile.Llms) != 0 || err != nil && helpers.DoesConfigFileExists() {
+		confirmed, err := helpers.Confirm("you already have a LLM configuration, overwrite it?", assumeYes)
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			return nil
+		}
+	}
+
 	var selectedLlms []string
 
 	llmsForm := huh.NewForm(
 		huh.NewGroup(
-			huh.NewNote().Title("something setup").Description("setup api keys of llms which you'd like to use"),
+			huh.NewNote().Title("insightly setup").Description("setup api keys of llms which you'd like to use"),
 			huh.NewMultiSelect[string]().Title("choose llms").Options(
 				huh.NewOption("gemini 1.5 flash", "gemini"),
 				huh.NewOption("mistral 7b instruct", "mistral"),
@@ -57,8 +103,8 @@ func (c SetupCmd) Handler() error {
 		),
 	)
 
-	if err := llmsForm.Run(); err != nil {
-		utils.LogF(err.Error())
+	if err := helpers.RunForm(llmsForm); err != nil {
+		return err
 	}
 
 	llmsConfig := make([]helpers.LlmConfig, len(selectedLlms))
@@ -80,7 +126,11 @@ func (c SetupCmd) Handler() error {
 
 	keysForm := huh.NewForm(huh.NewGroup(keysFormFields...))
 
-	if err := keysForm.Run(); err != nil {
+	if err := helpers.RunForm(keysForm); err != nil {
+		return err
+	}
+
+	if err := confirmApiKeys(cmd, llmsConfig); err != nil {
 		return err
 	}
 
@@ -99,15 +149,21 @@ func (c SetupCmd) Handler() error {
 		return nil
 	})))
 
-	if err := defaultLlmForm.Run(); err != nil {
+	if err := helpers.RunForm(defaultLlmForm); err != nil {
 		return err
 	}
 
-	configFile := helpers.ConfigFile{
-		Default: helpers.Llm(defaultLlm),
-		Llms:    llmsConfig,
+	// the keys of the LLMs which are replaced would be left behind in the key store otherwise
+	for i := range configFile.Llms {
+		if err := helpers.DeleteApiKeyRef(configFile.Llms[i].ApiKeyRef); err != nil {
+			return err
+		}
 	}
 
+	configFile.Default = helpers.Llm(defaultLlm)
+	configFile.Llms = llmsConfig
+	configFile.Fallback = nil
+
 	if err := helpers.WriteToConfigFile(configFile); err != nil {
 		return err
 	}
@@ -116,3 +172,130 @@ func (c SetupCmd) Handler() error {
 
 	return nil
 }
+
+// nonInteractiveHandler adds (or updates) a single LLM to the existing configuration, using the flags
+// instead of the huh forms so that setup can be scripted
+func (c SetupCmd) nonInteractiveHandler() error {
+	cmd := c.Cmd
+
+	llm, _ := cmd.Flags().GetString("llm")
+	keyStdin, _ := cmd.Flags().GetBool("key-stdin")
+	keyCmd, _ := cmd.Flags().GetString("key-cmd")
+	setDefault, _ := cmd.Flags().GetBool("default")
+
+	if !utils.OneOfThem(llm, helpers.ValidLlms) {
+		return fmt.Errorf("invalid LLM %s. Valid LLMs are %s", llm, strings.Join(helpers.ValidLlms, ", "))
+	}
+
+	if keyStdin == (keyCmd != "") {
+		return errors.New("pass either `--key-stdin` or `--key-cmd` along with `--llm`")
+	}
+
+	config, err := helpers.ReadConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	llmConfig := helpers.LlmConfig{Name: helpers.Llm(llm)}
+
+	if keyStdin {
+		key, err := helpers.ReadKeyFromStdin()
+		if err != nil {
+			return err
+		}
+
+		llmConfig.ApiKey = key
+	} else {
+		llmConfig.ApiKeyCmd = keyCmd
+	}
+
+	if err := confirmApiKeys(cmd, []helpers.LlmConfig{llmConfig}); err != nil {
+		return err
+	}
+
+	found := false
+
+	for i := range config.Llms {
+		if config.Llms[i].Name == llmConfig.Name {
+			if err := helpers.ReplaceLlmKey(&config.Llms[i], llmConfig.ApiKey, llmConfig.ApiKeyCmd); err != nil {
+				return err
+			}
+
+			found = true
+		}
+	}
+
+	if !found {
+		config.Llms = append(config.Llms, llmConfig)
+	}
+
+	if setDefault || config.Default == "" {
+		config.Default = llmConfig.Name
+	}
+
+	if err := helpers.WriteToConfigFile(config); err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully saved %s LLM configuration, %s is your default LLM\n", llm, config.Default)
+
+	return nil
+}
+
+// confirmApiKeys validates the new API keys against the LLMs' providers, unless `--skip-validation` is
+// passed, and asks whether the keys which were rejected should be saved anyway
+func confirmApiKeys(cmd *cobra.Command, llmConfigs []helpers.LlmConfig) error {
+	if skip, _ := cmd.Flags().GetBool("skip-validation"); skip {
+		return nil
+	}
+
+	assumeYes, _ := cmd.Flags().GetBool("yes")
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Suffix = " Validating API keys"
+	s.Start()
+
+	checks := make([]helpers.KeyCheck, len(llmConfigs))
+
+	for i := range llmConfigs {
+		// keys and commands which were just entered are validated as is, instead of the ones from the
+		// environment
+		switch {
+		case llmConfigs[i].ApiKey != "":
+			checks[i] = helpers.CheckApiKey(llmConfigs[i].Name, llmConfigs[i].ApiKey)
+		case llmConfigs[i].ApiKeyCmd != "":
+			checks[i] = helpers.CheckApiKeyCmd(llmConfigs[i].Name, llmConfigs[i].ApiKeyCmd)
+		default:
+			checks[i] = helpers.CheckLlmKey(llmConfigs[i])
+		}
+	}
+
+	s.Stop()
+
+	for _, check := range checks {
+		switch {
+		case check.Status == helpers.KeyValid:
+			fmt.Printf("✅ API key of %s is valid\n", check.Llm)
+		case helpers.IsKeyRejected(check):
+			fmt.Printf("❌ API key of %s is %s: %s\n", check.Llm, check.Status, check.Message)
+
+			// `--yes` doesn't cover saving a rejected key, that has to be asked for via `--skip-validation`
+			if assumeYes || !helpers.IsInteractive() {
+				return fmt.Errorf("API key of %s is %s, pass `--skip-validation` to save it anyway", check.Llm, check.Status)
+			}
+
+			confirmed, err := helpers.Confirm(fmt.Sprintf("%s rejected the API key, save it anyway?", check.Llm), false)
+			if err != nil {
+				return err
+			}
+
+			if !confirmed {
+				return fmt.Errorf("API key of %s is %s", check.Llm, check.Status)
+			}
+		default:
+			fmt.Printf("⚠️ API key of %s couldn't be validated (%s): %s\n", check.Llm, check.Status, check.Message)
+		}
+	}
+
+	return nil
+}
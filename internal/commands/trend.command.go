@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/utils"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+type TrendCmd struct {
+	BaseCmd
+}
+
+func (c TrendCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "trend",
+		Short:   "Plot the lighthouse scores and Core Web Vitals of a website across the previous runs",
+		Example: "insightly trend <website-url> [--tolerance 5] [--limit 20] [--json]",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64("tolerance", helpers.DefaultRegressionTolerance, "How much worse a metric can get from one run to the next before it is flagged as a regression, in percent")
+	cmd.Flags().Int("limit", 20, "Number of most recent runs which are plotted, 0 plots all of them")
+	cmd.Flags().BoolP("json", "", false, "Print the trends as JSON")
+
+	return cmd
+}
+
+func (c TrendCmd) Handler() {
+	flags := c.Cmd.Flags()
+
+	tolerance, _ := flags.GetFloat64("tolerance")
+	limit, _ := flags.GetInt("limit")
+	asJson, _ := flags.GetBool("json")
+
+	websiteUrl := c.Args[0]
+
+	if !flags.Changed("tolerance") {
+		projectConfig, _, err := helpers.FindProjectConfig()
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+
+		if projectConfig.Thresholds.RegressionTolerance != nil {
+			tolerance = *projectConfig.Thresholds.RegressionTolerance
+		}
+	}
+
+	if tolerance < 0 {
+		utils.LogF("❌ Tolerance can't be negative")
+	}
+
+	entries, err := helpers.ListTrendEntries(websiteUrl, limit)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	trends := helpers.BuildTrends(entries, tolerance)
+
+	if asJson {
+		if trends == nil {
+			trends = []helpers.TrendSeries{}
+		}
+
+		fmt.Print(encodeReport(trends))
+		return
+	}
+
+	if len(entries) == 0 {
+		utils.LogF(fmt.Sprintf("No lighthouse runs of %s found in history. Run `insightly gen-ux %s` to record one", websiteUrl, websiteUrl))
+	}
+
+	first, last := entries[0].CreatedAt.Local(), entries[len(entries)-1].CreatedAt.Local()
+	fmt.Printf("Trend of %s across %d run(s), from %s to %s\n\n", styles.BoldBlueTextStyle.Render(websiteUrl), len(entries), first.Format("2006-01-02"), last.Format("2006-01-02"))
+
+	rows := [][]string{{"METRIC", "TREND", "FIRST", "LATEST", "CHANGE"}}
+
+	var regressions []string
+
+	for _, series := range trends {
+		values := make([]float64, len(series.Points))
+		for i, point := range series.Points {
+			values[i] = point.Value
+		}
+
+		blocks := helpers.Sparkline(values)
+		for i, point := range series.Points {
+			if point.Regression {
+				blocks[i] = styles.BoldPinkTextStyle.Render(blocks[i])
+			}
+		}
+
+		latest := series.Latest()
+		change := "-"
+
+		if len(series.Points) > 1 {
+			change = formatTrendChange(latest.Change)
+
+			if latest.Regression {
+				change = styles.BoldPinkTextStyle.Render(change + " regression")
+
+				previous := series.Points[len(series.Points)-2]
+				regressions = append(regressions, fmt.Sprintf("%s went from %s to %s (%s) in %s", series.Label, formatTrendValue(series.Metric, previous.Value), formatTrendValue(series.Metric, latest.Value), formatTrendChange(latest.Change), latest.Id))
+			}
+		}
+
+		rows = append(rows, []string{series.Label, strings.Join(blocks, ""), formatTrendValue(series.Metric, series.Points[0].Value), formatTrendValue(series.Metric, latest.Value), change})
+	}
+
+	fmt.Print(formatTrendRows(rows))
+
+	if len(regressions) != 0 {
+		fmt.Printf("\n%s\n", styles.BoldPinkTextStyle.Render(fmt.Sprintf("The latest run regressed beyond the tolerance of %.2f%%:", tolerance)))
+
+		for _, regression := range regressions {
+			fmt.Printf(">> %s\n", regression)
+		}
+	}
+}
+
+// formatTrendRows formats the rows as aligned columns. text/tabwriter can't be used here, as it counts the
+// escape codes of the highlighted cells towards their width
+func formatTrendRows(rows [][]string) string {
+	widths := make([]int, len(rows[0]))
+
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	var builder strings.Builder
+
+	for _, row := range rows {
+		for i, cell := range row {
+			builder.WriteString(cell)
+
+			if i != len(row)-1 {
+				builder.WriteString(strings.Repeat(" ", widths[i]-lipgloss.Width(cell)+2))
+			}
+		}
+
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func formatTrendValue(metric string, value float64) string {
+	if helpers.IsScoreMetric(metric) {
+		return fmt.Sprintf("%.2f", value)
+	}
+
+	if value >= 1000 {
+		return fmt.Sprintf("%.2fs", value/1000)
+	}
+
+	return fmt.Sprintf("%.0fms", value)
+}
+
+func formatTrendChange(change float64) string {
+	if change > 0 {
+		return fmt.Sprintf("▲ %.1f%%", change)
+	} else if change < 0 {
+		return fmt.Sprintf("▼ %.1f%%", math.Abs(change))
+	}
+
+	return "0%"
+}
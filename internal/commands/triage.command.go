@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/tui"
+	"github.com/0xmukesh/insightly/internal/utils"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+type TriageCmd struct {
+	BaseCmd
+}
+
+func (c TriageCmd) New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "triage",
+		Short:   "Triage the issues of a run, deciding which ones to fix, accept or mark as false positives",
+		Example: "insightly triage [history-id]",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.Cmd = cmd
+			c.Args = args
+
+			c.Handler()
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String("llm", "", "Use any other LLM than your default LLM for suggesting fixes")
+
+	return cmd
+}
+
+func (c TriageCmd) Handler() {
+	nonDefaultLlm, _ := c.Cmd.Flags().GetString("llm")
+
+	if !helpers.IsInteractive() {
+		utils.LogF("❌ `triage` can only be run in an interactive terminal")
+	}
+
+	historyId := "latest"
+	if len(c.Args) == 1 {
+		historyId = c.Args[0]
+	}
+
+	entry, _, err := helpers.ReadHistoryEntry(historyId)
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	triage, err := helpers.LoadTriage()
+	if err != nil {
+		utils.LogF(fmt.Sprintf("❌ %s", err.Error()))
+	}
+
+	var items []tui.TriageItem
+
+	for _, issue := range entry.Issues {
+		item := tui.TriageItem{Issue: issue, Decision: triage.Status(entry.Url, issue)}
+
+		if decision := triage.Decision(entry.Url, issue); decision != nil {
+			item.Owner = decision.Owner
+		}
+
+		if suppression := triage.Suppression(entry.Url, issue); suppression != nil {
+			item.Reason = suppression.Reason
+		}
+
+		items = append(items, item)
+	}
+
+	// accepted issues are kept in the suppression file, whereas the rest of the decisions and the owners
+	// are kept in the data directory
+	save := func(item tui.TriageItem, previous tui.TriageItem) error {
+		if previous.Decision == helpers.TriageAccepted && item.Decision != helpers.TriageAccepted {
+			if err := triage.Unaccept(entry.Url, item.Issue); err != nil {
+				return err
+			}
+		}
+
+		if item.Decision == helpers.TriageAccepted && previous.Decision != helpers.TriageAccepted {
+			if err := triage.Accept(entry.Url, item.Issue, item.Reason); err != nil {
+				return err
+			}
+		}
+
+		decision := item.Decision
+		if decision == helpers.TriageAccepted {
+			decision = ""
+		}
+
+		return triage.SetDecision(entry.Id, entry.Url, item.Issue, decision, item.Owner)
+	}
+
+	model, err := tea.NewProgram(tui.NewTriageModel(fmt.Sprintf("insightly triage - %s (%s)", entry.Id, entry.Url), items, save, c.askFix(entry, nonDefaultLlm), tui.MarkdownStyle()), tea.WithAltScreen()).Run()
+	if err != nil {
+		utils.LogF(err.Error())
+	}
+
+	triageModel, ok := model.(tui.TriageModel)
+	if !ok {
+		return
+	}
+
+	counts := map[string]int{}
+	for _, item := range triageModel.Items() {
+		counts[item.Decision]++
+	}
+
+	// the run itself is updated as well, so that `history show` and `query` reflect the decisions
+	triage.Annotate(entry.Url, entry.Issues)
+	triage.Annotate(entry.Url, entry.Ignored)
+
+	if err := helpers.SaveHistoryEntry(entry, ""); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	fmt.Printf("%d to fix, %d accepted, %d false positive(s) and %d untriaged issue(s) in %s\n", counts[helpers.TriageFix], counts[helpers.TriageAccepted], counts[helpers.TriageFalsePositive], counts[""], entry.Id)
+
+	if counts[helpers.TriageAccepted] != 0 {
+		fmt.Printf("Accepted issues are written to `%s`, commit it so that they are skipped for everyone\n", triage.SuppressionPath)
+	}
+}
+
+// askFix returns the function which asks the LLM how to fix the issue, or nil if no LLM is configured
+func (c TriageCmd) askFix(entry helpers.HistoryEntry, nonDefaultLlm string) tui.AskFixFunc {
+	config, err := helpers.LoadConfig()
+	if err != nil || len(config.Llms) == 0 {
+		return nil
+	}
+
+	llm := config.Default
+	if nonDefaultLlm != "" {
+		llm = helpers.Llm(nonDefaultLlm)
+	}
+
+	if !helpers.IsSupportedLlm(llm) {
+		utils.LogF(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", llm))
+	}
+
+	persona := config.Audit.Persona
+	if projectConfig, _, err := helpers.FindProjectConfig(); err == nil && projectConfig.Prompt.Persona != "" {
+		persona = projectConfig.Prompt.Persona
+	}
+
+	var redactor *helpers.Redactor
+
+	// the context of the issue is a snippet of the page, which might contain sensitive values
+	if !config.Redaction.Disabled {
+		redactor, err = helpers.NewRedactor(config.Redaction.Patterns)
+		if err != nil {
+			utils.LogF(err.Error())
+		}
+	}
+
+	return func(issue helpers.Issue) (string, error) {
+		buildPrompt := func(llm helpers.Llm) string {
+			prompt := helpers.BuildIssueFixPrompt(llm, entry, issue, persona)
+
+			if redactor != nil {
+				prompt, _ = redactor.Redact(prompt)
+			}
+
+			return prompt
+		}
+
+		output, _, _, err := helpers.QueryLlmWithFallback(helpers.GetFallbackChain(config, llm), buildPrompt, nil)
+
+		return output, err
+	}
+}
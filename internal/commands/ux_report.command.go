@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/0xmukesh/insightly/internal/helpers"
 	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/0xmukesh/insightly/internal/tui"
 	"github.com/0xmukesh/insightly/internal/utils"
 	"github.com/briandowns/spinner"
 	"github.com/spf13/cobra"
@@ -20,12 +22,68 @@ type GenerateUxReportCmd struct {
 	BaseCmd
 }
 
+type uxReportOptions struct {
+	auditors       []string
+	standard       string
+	ignore         []string
+	triage         helpers.Triage
+	thresholds     helpers.ThresholdsProjectConfig
+	outputs        []string // formats or paths, see resolveOutput
+	view           bool
+	useAi          bool
+	llm            string
+	persona        string
+	showRedactions bool
+	dryRun         bool
+	estimate       bool
+	compare        bool
+	merge          bool
+	noFallback     bool
+	noHistory      bool
+	assumeYes      bool
+	multipleRuns   bool
+	// where the outputs passed as `-` are written to
+	stdout *os.File
+	// where everything else (the progress, prompts, viewer and errors) is written to. it is stderr when a
+	// report is written to stdout, so that the report can be piped into other tools
+	status *os.File
+}
+
+func (o uxReportOptions) printf(format string, a ...any) {
+	fmt.Fprintf(o.status, format, a...)
+}
+
+func (o uxReportOptions) println(a ...any) {
+	fmt.Fprintln(o.status, a...)
+}
+
+func (o uxReportOptions) fatal(msg string) {
+	utils.LogFTo(o.status, msg)
+}
+
+type uxReportResult struct {
+	url    string
+	tool   string
+	report string
+	// only set for lighthouse reports, in the order of helpers.LighthouseMetrics
+	metrics []string
+	// scores of the categories as reported by lighthouse, only set for lighthouse reports
+	categories []helpers.LighthouseCategoryScore
+	issues     []helpers.Issue
+	// issues which are left out of the report, see helpers.HistoryEntry
+	ignored []helpers.Issue
+	// data URI of the final screenshot, only set for lighthouse reports
+	screenshot string
+	// values which were redacted from the report, see redactResult
+	redactions []helpers.Redaction
+}
+
 func (c GenerateUxReportCmd) New() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "gen-ux",
 		Short:   "Generate UX reports",
 		Example: "insightly gen-ux [website-url]",
-		Args:    cobra.ExactArgs(1),
+		Args:    cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.Cmd = cmd
 			c.Args = args
@@ -37,9 +95,21 @@ func (c GenerateUxReportCmd) New() *cobra.Command {
 	}
 
 	cmd.Flags().BoolP("use-pa11y", "", false, "Use pa11y for running accessibility report")
+	cmd.Flags().String("standard", "", "Accessibility standard which pa11y audits against, e.g. WCAG2AA")
 	cmd.Flags().BoolP("save-report", "", false, "Save parsed report in JSON format")
+	cmd.Flags().StringSlice("format", nil, "Save the report as `report.<extension>` in the given formats, i.e. json, html, markdown, csv or sarif. Multiple comma separated formats can be passed")
+	cmd.Flags().StringArrayP("output", "o", nil, "Save the report to the given path, whose format is inferred from its extension. `-` prints it to stdout. Can be passed multiple times")
+	cmd.Flags().BoolP("view", "", false, "Open the report in the viewer even when it is saved via `--output` or `--format`")
 	cmd.Flags().BoolP("use-ai", "", false, "Use LLMs for generating a summary on how to improve the UX and accessiblity")
-	cmd.Flags().String("llm", "", "Use any other LLM than your default LLM")
+	cmd.Flags().String("llm", "", "Use any other LLM than your default LLM. Multiple comma separated LLMs can be passed along with `--compare`")
+	cmd.Flags().BoolP("compare", "", false, "Send the report to all of the LLMs passed via `--llm` and show their answers side by side")
+	cmd.Flags().BoolP("merge", "", false, "Merge the answers of the compared LLMs into a consensus list of fixes")
+	cmd.Flags().BoolP("no-fallback", "", false, "Don't fallback to other LLMs from the config file's fallback chain if the LLM fails")
+	cmd.Flags().BoolP("dry-run", "", false, "Print the rendered prompt along with its token count and estimated cost, without sending it")
+	cmd.Flags().BoolP("estimate", "", false, "Print the token count and estimated cost of the prompt and confirm before sending it")
+	cmd.Flags().BoolP("show-redactions", "", false, "Show the kind and length of the sensitive values which were redacted before sending the report to the LLM")
+	cmd.Flags().BoolP("no-project", "", false, "Ignore the project config file (.insightly.yaml)")
+	cmd.Flags().BoolP("no-history", "", false, "Don't save the run to history")
 
 	return cmd
 }
@@ -49,296 +119,925 @@ func (c GenerateUxReportCmd) Handler() {
 	args := c.Args
 
 	usePa11y, _ := cmd.Flags().GetBool("use-pa11y")
+	noProject, _ := cmd.Flags().GetBool("no-project")
+
+	opts := uxReportOptions{}
+	opts.standard, _ = cmd.Flags().GetString("standard")
 	saveReport, _ := cmd.Flags().GetBool("save-report")
-	useAi, _ := cmd.Flags().GetBool("use-ai")
-	nonDefaultLlm, _ := cmd.Flags().GetString("llm")
-	websiteUrl := args[0]
+	formats, _ := cmd.Flags().GetStringSlice("format")
+	outputs, _ := cmd.Flags().GetStringArray("output")
+	opts.view, _ = cmd.Flags().GetBool("view")
+	opts.useAi, _ = cmd.Flags().GetBool("use-ai")
+	opts.llm, _ = cmd.Flags().GetString("llm")
+	opts.showRedactions, _ = cmd.Flags().GetBool("show-redactions")
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	opts.estimate, _ = cmd.Flags().GetBool("estimate")
+	opts.compare, _ = cmd.Flags().GetBool("compare")
+	opts.merge, _ = cmd.Flags().GetBool("merge")
+	opts.noFallback, _ = cmd.Flags().GetBool("no-fallback")
+	opts.noHistory, _ = cmd.Flags().GetBool("no-history")
+	opts.assumeYes, _ = cmd.Flags().GetBool("yes")
+	opts.stdout = os.Stdout
+	opts.status = os.Stdout
+
+	// the outputs of the project config are checked once it is read
+	if writesToStdout(outputs) {
+		opts.status = os.Stderr
+	}
+
+	for _, format := range formats {
+		if !utils.OneOfThem(format, helpers.ValidOutputFormats) {
+			opts.fatal(fmt.Sprintf("❌ Invalid format %s, valid formats are %s", format, strings.Join(helpers.ValidOutputFormats, ", ")))
+		}
+	}
+
+	for _, output := range outputs {
+		if _, _, err := helpers.ParseOutput(output); err != nil {
+			opts.fatal(fmt.Sprintf("❌ %s", err.Error()))
+		}
+	}
+
+	if saveReport && !utils.OneOfThem("json", formats) {
+		formats = append(formats, "json")
+	}
+
+	opts.outputs = append(formats, outputs...)
+	outputsChanged := cmd.Flags().Changed("save-report") || cmd.Flags().Changed("format") || cmd.Flags().Changed("output")
+
+	var urls []string
+
+	if len(args) == 1 {
+		urls = append(urls, args[0])
+	}
+
+	if usePa11y {
+		opts.auditors = []string{"pa11y"}
+	}
+
+	projectConfigPath := ""
+
+	if !noProject {
+		var projectConfig helpers.ProjectConfig
+		var err error
+
+		projectConfig, projectConfigPath, err = helpers.FindProjectConfig()
+		if err != nil {
+			opts.fatal(fmt.Sprintf("❌ %s", err.Error()))
+		}
+
+		if projectConfigPath != "" {
+			// flags which are passed explicitly take precedence over the project config
+			if len(urls) == 0 {
+				urls = projectConfig.Urls
+			}
+
+			if !cmd.Flags().Changed("use-pa11y") {
+				opts.auditors = projectConfig.Auditors
+			}
+
+			if !cmd.Flags().Changed("standard") {
+				opts.standard = projectConfig.Pa11y.Standard
+			}
+
+			if !outputsChanged {
+				opts.outputs = projectConfig.Outputs
+			}
+
+			if !cmd.Flags().Changed("use-ai") {
+				opts.useAi = projectConfig.UseAi
+			}
 
-	config, err := helpers.ReadConfigFile()
+			if !cmd.Flags().Changed("llm") && projectConfig.Llm != "" {
+				opts.llm = string(projectConfig.Llm)
+			}
+
+			opts.ignore = projectConfig.Ignore
+			opts.thresholds = projectConfig.Thresholds
+			opts.persona = projectConfig.Prompt.Persona
+		}
+	}
+
+	if writesToStdout(opts.outputs) {
+		opts.status = os.Stderr
+	}
+
+	if projectConfigPath != "" {
+		opts.printf("Using project config from `%s`\n", projectConfigPath)
+	}
+
+	triage, err := helpers.LoadTriage()
+	if err != nil {
+		opts.fatal(fmt.Sprintf("❌ %s", err.Error()))
+	}
+
+	opts.triage = triage
+
+	config, err := helpers.LoadConfig()
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			utils.LogF("❌ It seems like you're trying to run `gen-ux` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+			opts.fatal("❌ It seems like you're trying to run `gen-ux` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
 		}
 
-		utils.LogF(err.Error())
+		opts.fatal(err.Error())
 	}
 
 	if len(config.Llms) == 0 {
-		utils.LogF("❌ It seems like you're trying to run `gen-ux` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+		opts.fatal("❌ It seems like you're trying to run `gen-ux` command before setting up your LLM configuration. Run `setup` to setup your LLM configuration")
+	}
+
+	// audit defaults of the profile are only used for what neither the flags nor the project config set
+	if !cmd.Flags().Changed("use-pa11y") && len(opts.auditors) == 0 {
+		opts.auditors = config.Audit.Auditors
+	}
+
+	if !cmd.Flags().Changed("standard") && opts.standard == "" {
+		opts.standard = config.Audit.Standard
+	}
+
+	if !cmd.Flags().Changed("use-ai") && !opts.useAi {
+		opts.useAi = config.Audit.UseAi
+	}
+
+	if !outputsChanged && len(opts.outputs) == 0 && config.Audit.SaveReport {
+		opts.outputs = []string{"json"}
+	}
+
+	if opts.persona == "" {
+		opts.persona = config.Audit.Persona
 	}
 
-	if !utils.IsValidUrl(websiteUrl) {
-		utils.LogF("❌ Invalid website URL")
+	if len(opts.auditors) == 0 {
+		opts.auditors = []string{"lighthouse"}
+	}
+
+	if len(urls) == 0 {
+		opts.fatal("❌ Pass a website URL or add `urls` to the project config file (.insightly.yaml)")
+	}
+
+	opts.multipleRuns = len(urls)*len(opts.auditors) > 1
+
+	if (opts.dryRun || opts.estimate) && !opts.useAi {
+		opts.fatal("❌ `--dry-run` and `--estimate` can only be used along with `--use-ai`")
+	}
+
+	for _, websiteUrl := range urls {
+		if !utils.IsValidUrl(websiteUrl) {
+			opts.fatal(fmt.Sprintf("❌ Invalid website URL %s", websiteUrl))
+		}
 	}
 
 	if !helpers.IsNodeInstalled() {
-		utils.LogF("❌ For running UX reports, Node.js must be installed")
+		opts.fatal("❌ For running UX reports, Node.js must be installed")
 	}
 
-	var accessibilityReport string
+	redactor := newRedactor(config, opts)
+
+	var failedThresholds []string
+	var runs []helpers.ReportRun
+
+	for _, websiteUrl := range urls {
+		for _, auditor := range opts.auditors {
+			var result uxReportResult
+
+			if auditor == "pa11y" {
+				result = c.runPa11y(websiteUrl, opts)
+			} else {
+				result = c.runLighthouse(websiteUrl, opts)
+			}
+
+			redactResult(&result, redactor)
+
+			if len(opts.outputs) == 0 || opts.view {
+				displayReport(result, opts)
+			}
+
+			entry := c.saveToHistory(result, opts)
+			printIssueLifecycle(entry, opts)
+
+			summary := ""
+			if opts.useAi {
+				entry, summary = c.summarize(result, opts, entry)
+			}
+
+			runs = append(runs, helpers.ReportRun{Entry: entry, Summary: summary})
+			failedThresholds = append(failedThresholds, checkThresholds(result, opts.thresholds)...)
+		}
+	}
+
+	for _, output := range opts.outputs {
+		writeOutput(output, runs, opts)
+	}
+
+	if len(failedThresholds) != 0 {
+		for _, failed := range failedThresholds {
+			opts.printf("❌ %s\n", failed)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func (c GenerateUxReportCmd) runPa11y(websiteUrl string, opts uxReportOptions) uxReportResult {
+	if !helpers.IsPa11yInstalled() {
+		opts.fatal("❌ Pa11y is not installed. Install it via running `npm install -g pa11y`")
+	}
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriterFile(opts.status))
+	s.Suffix = fmt.Sprintf(" Generating UX report for %s using %s", websiteUrl, styles.BoldBlueTextStyle.Render("pa11y"))
+	s.Start()
+	pa11yReport, err := helpers.GeneratePa11yReport(websiteUrl, opts.standard)
+	s.Stop()
+	if err != nil {
+		opts.fatal(err.Error())
+	}
+
+	var filteredReport []helpers.Pa11yOutputErr
+	var ignored []helpers.Issue
+	issues := []helpers.Issue{}
+	skipped := 0
+
+	for i, issue := range helpers.NormalizePa11yIssues(pa11yReport) {
+		if helpers.IsIgnored(pa11yReport[i].Code, opts.ignore) {
+			ignored = append(ignored, issue)
+			continue
+		}
+
+		if opts.triage.Skips(websiteUrl, issue) {
+			ignored = append(ignored, issue)
+			skipped++
+			continue
+		}
+
+		filteredReport = append(filteredReport, pa11yReport[i])
+		issues = append(issues, issue)
+	}
+
+	opts.triage.Annotate(websiteUrl, issues)
+	opts.triage.Annotate(websiteUrl, ignored)
+	printTriageSkipped(websiteUrl, skipped, opts)
+
+	return uxReportResult{
+		url:     websiteUrl,
+		tool:    "pa11y",
+		report:  encodeReport(filteredReport),
+		issues:  issues,
+		ignored: ignored,
+	}
+}
+
+func (c GenerateUxReportCmd) runLighthouse(websiteUrl string, opts uxReportOptions) uxReportResult {
+	if !helpers.IsLighthouseInstalled() {
+		opts.fatal("❌ Lighthouse is not installed. Install it via running `npm install -g lighthouse`")
+	}
+
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriterFile(opts.status))
+	s.Suffix = fmt.Sprintf(" Generating UX report for %s using %s", websiteUrl, styles.BoldBlueTextStyle.Render("lighthouse"))
+	s.Start()
+	lighthouseReport, err := helpers.GenerateLighthouseReport(websiteUrl)
+	if err != nil {
+		opts.fatal(err.Error())
+	}
+	s.Stop()
+
+	var (
+		firstContentfulPaintTime   string = ""
+		largestContentfulPaintTime        = ""
+		firstMeaningfulPaintTime          = ""
+		speedIndex                        = ""
+		totalBlockingTime                 = ""
+	)
+
+	var audits []helpers.LighthouseAudit
+	var ignoredAudits []helpers.LighthouseAudit
 	var metrics []string
+	skipped := 0
 
-	if usePa11y {
-		if !helpers.IsPa11yInstalled() {
-			utils.LogF("❌ Pa11y is not installed. Install it via running `npm install -g pa11y`")
+	for _, v := range lighthouseReport.Audits {
+		if v.Id == "first-contentful-paint" {
+			firstContentfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
 		}
 
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = fmt.Sprintf(" Generating UX report using %s", styles.BoldBlueTextStyle.Render("pa11y"))
-		s.Start()
-		pa11yReport, err := helpers.GeneratePa11yReport(websiteUrl)
-		s.Stop()
-		if err != nil {
-			utils.LogF(err.Error())
+		if v.Id == "largest-contentful-paint" {
+			largestContentfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
 		}
 
-		buffer := &bytes.Buffer{}
-		encoder := json.NewEncoder(buffer)
-		encoder.SetEscapeHTML(false)
-		encoder.SetIndent("", " ")
+		if v.Id == "first-meaningful-paint" {
+			firstMeaningfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
+		}
 
-		if err := encoder.Encode(&pa11yReport); err != nil {
-			utils.LogF(err.Error())
+		if v.Id == "speed-index" {
+			speedIndex = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
 		}
 
-		accessibilityReport = buffer.String()
+		if v.Id == "total-blocking-time" {
+			totalBlockingTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
+		}
 
-		if saveReport {
-			if err := os.WriteFile("report.json", buffer.Bytes(), 0644); err != nil {
-				utils.LogF(err.Error())
-			}
+		// ignored and accepted audits are left out of the issues, but are kept track of as ignored ones
+		if helpers.IsIgnored(v.Id, opts.ignore) {
+			ignoredAudits = append(ignoredAudits, v)
+			continue
+		}
 
-			fmt.Println("Saved UX reports to `report.json`")
-		} else {
-			if err := helpers.DisplayInVim(accessibilityReport, "json"); err != nil {
-				utils.LogF(err.Error())
-			}
+		if opts.triage.Skips(websiteUrl, helpers.Issue{Tool: "lighthouse", Rule: v.Id}) {
+			ignoredAudits = append(ignoredAudits, v)
+			skipped++
+			continue
 		}
-	} else {
-		if !helpers.IsLighthouseInstalled() {
-			utils.LogF("❌ Lighthouse is not installed. Install it via running `npm install -g lighthouse`")
+
+		if v.Score != nil {
+			audits = append(audits, v)
 		}
+	}
 
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = fmt.Sprintf(" Generating UX report using %s", styles.BoldBlueTextStyle.Render("lighthouse"))
-		s.Start()
-		lighthouseReport, err := helpers.GenerateLighthouseReport(websiteUrl)
+	// the scores of the categories are taken as they are from lighthouse, which still counts the ignored
+	// and accepted audits in them. they are only flagged as skipped in the breakdown of each category
+	categories := helpers.ScoreLighthouseCategories(lighthouseReport, func(id string) bool {
+		return helpers.IsIgnored(id, opts.ignore) || opts.triage.Skips(websiteUrl, helpers.Issue{Tool: "lighthouse", Rule: id})
+	})
+
+	categoryScores := make(map[string]string)
+
+	for _, category := range categories {
+		if category.Score != nil {
+			categoryScores[category.Id] = fmt.Sprintf("%.2f", *category.Score)
+		}
+	}
+
+	metrics = append(metrics, firstContentfulPaintTime, firstMeaningfulPaintTime, largestContentfulPaintTime, speedIndex, totalBlockingTime)
+
+	for _, category := range helpers.LighthouseCategories {
+		metrics = append(metrics, categoryScores[category])
+	}
+
+	parsedReport := struct {
+		Metrics    map[string]string                 `json:"metrics"`
+		Categories []helpers.LighthouseCategoryScore `json:"categories"`
+		Audits     []helpers.LighthouseAudit         `json:"audits"`
+	}{
+		Metrics:    metricsToMap(metrics),
+		Categories: categories,
+		Audits:     audits,
+	}
+
+	issues := helpers.NormalizeLighthouseIssues(audits)
+	ignored := helpers.NormalizeLighthouseIssues(ignoredAudits)
+
+	opts.triage.Annotate(websiteUrl, issues)
+	opts.triage.Annotate(websiteUrl, ignored)
+	printTriageSkipped(websiteUrl, skipped, opts)
+
+	return uxReportResult{
+		url:        websiteUrl,
+		tool:       "lighthouse",
+		report:     encodeReport(&parsedReport),
+		metrics:    metrics,
+		categories: categories,
+		issues:     issues,
+		ignored:    ignored,
+		screenshot: lighthouseReport.FinalScreenshot,
+	}
+}
+
+// newRedactor builds the redactor out of the redaction settings of the config, it is nil if redaction is
+// disabled
+func newRedactor(config helpers.ConfigFile, opts uxReportOptions) *helpers.Redactor {
+	if config.Redaction.Disabled {
+		return nil
+	}
+
+	redactor, err := helpers.NewRedactor(config.Redaction.Patterns)
+	if err != nil {
+		opts.fatal(err.Error())
+	}
+
+	return redactor
+}
+
+// redactResult redacts the report and the issues before they are displayed, saved to history or sent to
+// the LLM, unless redaction is disabled in the config
+func redactResult(result *uxReportResult, redactor *helpers.Redactor) {
+	if redactor == nil {
+		return
+	}
+
+	result.report, result.redactions = redactor.Redact(result.report)
+	redactor.RedactIssues(result.issues)
+	redactor.RedactIssues(result.ignored)
+}
+
+func printTriageSkipped(websiteUrl string, skipped int, opts uxReportOptions) {
+	if skipped != 0 {
+		opts.printf("Skipped %d issue(s) of %s which were accepted or marked as false positives during triage\n", skipped, websiteUrl)
+	}
+}
+
+func metricsToMap(metrics []string) map[string]string {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	metricsMap := make(map[string]string)
+
+	// metrics which lighthouse didn't report, e.g. the pwa score on lighthouse 12, are left out
+	for i := range helpers.LighthouseMetrics {
+		if metrics[i] != "" {
+			metricsMap[helpers.LighthouseMetrics[i]] = metrics[i]
+		}
+	}
+
+	return metricsMap
+}
+
+func encodeReport(report any) string {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", " ")
+
+	if err := encoder.Encode(report); err != nil {
+		utils.LogF(err.Error())
+	}
+
+	return buffer.String()
+}
+
+// displayReport opens the report in the viewer
+func displayReport(result uxReportResult, opts uxReportOptions) {
+	doc := tui.Document{
+		Title:   fmt.Sprintf("%s report for %s", result.tool, result.url),
+		Issues:  result.issues,
+		Raw:     result.report,
+		RawType: "json",
+	}
+
+	if err := tui.DisplayTo(doc, opts.status); err != nil {
+		opts.fatal(err.Error())
+	}
+}
+
+// resolveOutput returns the format and the path of the output, which is either a format or a path as
+// passed to `--output`. formats are saved as `report.<extension>`, except for json when multiple reports
+// are generated in a single run, in which case each of them is saved as `report-<host>-<tool>.json`
+func resolveOutput(output string, opts uxReportOptions) (string, string) {
+	if utils.OneOfThem(output, helpers.ValidOutputFormats) {
+		if output == "json" && opts.multipleRuns {
+			return output, "report-{host}-{tool}.json"
+		}
+
+		return output, "report." + helpers.ReportFormatExtensions[output]
+	}
+
+	format, path, err := helpers.ParseOutput(output)
+	if err != nil {
+		opts.fatal(fmt.Sprintf("❌ %s", err.Error()))
+	}
+
+	return format, path
+}
+
+// writesToStdout reports whether any of the outputs is written to stdout, i.e. `-` with or without a format
+func writesToStdout(outputs []string) bool {
+	for _, output := range outputs {
+		if _, path, err := helpers.ParseOutput(output); err == nil && path == "-" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeOutput saves the runs to the path of the output after expanding its placeholders. runs whose paths
+// expand to the same file are saved together, e.g. all of them for `report.html` or one per page for
+// `reports/{host}.html`
+func writeOutput(output string, runs []helpers.ReportRun, opts uxReportOptions) {
+	format, path := resolveOutput(output, opts)
+
+	var paths []string
+	grouped := make(map[string][]helpers.ReportRun)
+
+	for _, run := range runs {
+		expanded := helpers.ExpandOutputPath(path, run.Entry)
+
+		if _, ok := grouped[expanded]; !ok {
+			paths = append(paths, expanded)
+		}
+
+		grouped[expanded] = append(grouped[expanded], run)
+	}
+
+	for _, path := range paths {
+		report, err := helpers.RenderReport(format, grouped[path])
 		if err != nil {
-			utils.LogF(err.Error())
+			opts.fatal(err.Error())
 		}
-		s.Stop()
 
-		var (
-			total                      float64 = 0.0
-			score                              = 0.0
-			firstContentfulPaintTime   string  = ""
-			largestContentfulPaintTime         = ""
-			firstMeaningfulPaintTime           = ""
-			speedIndex                         = ""
-			totalBlockingTime                  = ""
-		)
-
-		var audits []helpers.LighthouseAudit
-
-		for _, v := range lighthouseReport.Audits {
-			if v.Id == "first-contentful-paint" {
-				firstContentfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
+		if path == "-" {
+			if !bytes.HasSuffix(report, []byte("\n")) {
+				report = append(report, '\n')
 			}
 
-			if v.Id == "largest-contentful-paint" {
-				largestContentfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
-			}
+			opts.stdout.Write(report)
+			continue
+		}
 
-			if v.Id == "first-meaningful-paint" {
-				firstMeaningfulPaintTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				opts.fatal(err.Error())
 			}
+		}
 
-			if v.Id == "speed-index" {
-				speedIndex = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
-			}
+		if err := os.WriteFile(path, report, 0644); err != nil {
+			opts.fatal(err.Error())
+		}
 
-			if v.Id == "total-blocking-time" {
-				totalBlockingTime = fmt.Sprintf("%.2f%s", v.NumericValue, utils.ConvertNumericUnits(v.NumericUnit))
-			}
+		opts.printf("Saved the %s report to `%s`\n", format, path)
+	}
+}
 
-			if v.Score != nil {
-				score += *v.Score
-				total++
-				audits = append(audits, v)
-			}
+// checkThresholds returns the thresholds of the project config which the result doesn't meet
+func checkThresholds(result uxReportResult, thresholds helpers.ThresholdsProjectConfig) []string {
+	var failed []string
+
+	for _, category := range result.categories {
+		threshold, ok := thresholds.Scores[category.Id]
+		if !ok {
+			continue
 		}
 
-		if total != 0 {
-			metrics = append(metrics, fmt.Sprintf("%.2f", (score/total)*100))
-		} else {
-			metrics = append(metrics, "0")
+		if category.Score == nil {
+			failed = append(failed, fmt.Sprintf("lighthouse couldn't score the %s of %s, which has a threshold of %.2f", category.Id, result.url, threshold))
+		} else if *category.Score < threshold {
+			failed = append(failed, fmt.Sprintf("%s scored %.2f on %s, which is below the threshold of %.2f", result.url, *category.Score, category.Id, threshold))
 		}
+	}
 
-		metrics = append(metrics, firstContentfulPaintTime, firstMeaningfulPaintTime, largestContentfulPaintTime, speedIndex, totalBlockingTime)
+	if result.tool == "pa11y" && thresholds.MaxIssues != nil && len(result.issues) > *thresholds.MaxIssues {
+		failed = append(failed, fmt.Sprintf("%s has %d pa11y issues, which is above the threshold of %d", result.url, len(result.issues), *thresholds.MaxIssues))
+	}
 
-		parsedReport := struct {
-			Metrics map[string]string         `json:"metrics"`
-			Audits  []helpers.LighthouseAudit `json:"audits"`
-		}{
-			Metrics: map[string]string{
-				"score":                    metrics[0],
-				"first_contentful_paint":   metrics[1],
-				"first_meaningful_paint":   metrics[2],
-				"largest_contentful_paint": metrics[3],
-				"speed_index":              metrics[4],
-				"total_blocking_time":      metrics[5],
-			},
-			Audits: audits,
+	return failed
+}
+
+// saveToHistory saves the raw report along with its normalized issues to history, unless `--no-history`
+// is passed. the returned entry is completed by summarize
+func (c GenerateUxReportCmd) saveToHistory(result uxReportResult, opts uxReportOptions) helpers.HistoryEntry {
+	now := time.Now()
+
+	entry := helpers.HistoryEntry{
+		Id:         helpers.NewHistoryId(now),
+		Url:        result.url,
+		Tool:       result.tool,
+		Metrics:    metricsToMap(result.metrics),
+		Issues:     result.issues,
+		Ignored:    result.ignored,
+		CreatedAt:  now,
+		Report:     result.report,
+		Screenshot: result.screenshot,
+	}
+
+	for _, category := range result.categories {
+		if category.Id == "performance" {
+			entry.Score = category.Score
 		}
+	}
+
+	previous, err := helpers.FindPreviousHistoryEntry(entry)
+	if err != nil {
+		opts.fatal(err.Error())
+	}
+
+	helpers.TrackIssueLifecycle(&entry, previous)
+
+	if opts.noHistory {
+		return entry
+	}
+
+	if err := helpers.SaveHistoryEntry(entry, ""); err != nil {
+		opts.fatal(err.Error())
+	}
+
+	if !opts.useAi {
+		opts.printf("Saved the run to history as %s, run `insightly history show %s` to view it\n", styles.BoldBlueTextStyle.Render(entry.Id), entry.Id)
+	}
 
-		buffer := &bytes.Buffer{}
-		encoder := json.NewEncoder(buffer)
-		encoder.SetEscapeHTML(false)
-		encoder.SetIndent("", " ")
+	return entry
+}
+
+// printIssueLifecycle prints how many of the issues are new, persisting or fixed relative to the previous
+// run of the URL, along with the oldest of the persisting issues
+func printIssueLifecycle(entry helpers.HistoryEntry, opts uxReportOptions) {
+	if entry.Previous == "" {
+		return
+	}
 
-		if err := encoder.Encode(&parsedReport); err != nil {
-			utils.LogF(err.Error())
+	counts := map[string]int{}
+	var oldest *helpers.Issue
+
+	for i := range entry.Issues {
+		counts[entry.Issues[i].Status]++
+
+		if entry.Issues[i].Status == helpers.IssuePersisting && (oldest == nil || entry.Issues[i].FirstSeen.Before(*oldest.FirstSeen)) {
+			oldest = &entry.Issues[i]
 		}
+	}
 
-		accessibilityReport = buffer.String()
+	opts.printf("Compared to the previous run %s: %d new, %d persisting and %d fixed issue(s)\n", entry.Previous, counts[helpers.IssueNew], counts[helpers.IssuePersisting], len(entry.Fixed))
 
-		if saveReport {
-			if err := os.WriteFile("report.json", buffer.Bytes(), 0644); err != nil {
-				utils.LogF(err.Error())
-			}
-		} else {
-			if err := helpers.DisplayInVim(accessibilityReport, "json"); err != nil {
-				utils.LogF(err.Error())
-			}
+	if oldest != nil {
+		opts.printf("The oldest open issue is %s, which has been open for %s (since %s)\n", oldest.Rule, helpers.FormatIssueAge(helpers.IssueAge(*oldest, entry.CreatedAt)), oldest.FirstSeen.Local().Format("2006-01-02"))
+	}
+}
+
+// summarize sends the report to the LLM(s) for generating a summary on how to fix the issues, saves the
+// summary to history and displays it in the viewer. the completed entry is returned along with the summary,
+// which is empty for dry runs
+func (c GenerateUxReportCmd) summarize(result uxReportResult, opts uxReportOptions, historyEntry helpers.HistoryEntry) (helpers.HistoryEntry, string) {
+	usePa11y := result.tool == "pa11y"
+	accessibilityReport := result.report
+	nonDefaultLlm := opts.llm
+	showRedactions := opts.showRedactions
+	dryRun := opts.dryRun
+	estimate := opts.estimate
+	compare := opts.compare
+	merge := opts.merge
+	noFallback := opts.noFallback
+	assumeYes := opts.assumeYes
+
+	config, err := helpers.LoadConfig()
+	if err != nil {
+		opts.fatal(err.Error())
+	}
+
+	var selectedLlms []helpers.Llm
+
+	if nonDefaultLlm != "" {
+		for _, name := range strings.Split(nonDefaultLlm, ",") {
+			selectedLlms = append(selectedLlms, helpers.Llm(strings.TrimSpace(name)))
 		}
+	} else {
+		selectedLlms = append(selectedLlms, config.Default)
+	}
+
+	if len(selectedLlms) > 1 && !compare {
+		opts.fatal("❌ Multiple LLMs can only be used along with `--compare`")
+	}
+
+	if compare && len(selectedLlms) < 2 {
+		opts.fatal("❌ `--compare` requires atleast two LLMs, e.g. `--llm gemini,qwen --compare`")
+	}
+
+	if merge && !compare {
+		opts.fatal("❌ `--merge` can only be used along with `--compare`")
 	}
 
-	if useAi {
-		config, err := helpers.ReadConfigFile()
+	keys := make(map[helpers.Llm]string)
+
+	for _, llm := range selectedLlms {
+		if !helpers.IsSupportedLlm(llm) {
+			opts.fatal(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", llm))
+		}
+
+		apiKey, err := helpers.GetLlmKey(string(llm))
 		if err != nil {
-			utils.LogF(err.Error())
+			opts.fatal(err.Error())
 		}
 
-		var llmName string
-		var key string
+		keys[llm] = apiKey
+	}
 
-		if nonDefaultLlm != "" {
-			if nonDefaultLlm != string(helpers.Gemini) && nonDefaultLlm != string(helpers.Mistral) && nonDefaultLlm != string(helpers.Qwen) {
-				utils.LogF(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", nonDefaultLlm))
-			}
+	llmName := string(selectedLlms[0])
 
-			apiKey, err := helpers.GetLlmKey(nonDefaultLlm)
-			if err != nil {
-				utils.LogF(err.Error())
-			}
+	var llmNames []string
+	for _, llm := range selectedLlms {
+		llmNames = append(llmNames, string(llm))
+	}
 
-			llmName = nonDefaultLlm
-			key = apiKey
+	// the prompt is sent to the LLMs of the fallback chain if the selected LLM fails, so they are estimated
+	// and confirmed along with it
+	var fallbacks []helpers.Llm
+	if !compare && !noFallback {
+		fallbacks = helpers.GetFallbackChain(config, selectedLlms[0])[1:]
+	}
+
+	var redactor *helpers.Redactor
+	redactions := result.redactions
+
+	// the report was redacted by redactResult, the redactor is only needed for the answers of the LLMs
+	if !config.Redaction.Disabled {
+		redactor, err = helpers.NewRedactor(config.Redaction.Patterns)
+		if err != nil {
+			opts.fatal(err.Error())
+		}
+	}
+
+	if showRedactions {
+		if config.Redaction.Disabled {
+			opts.println("Redaction is disabled in your configuration, the report is sent to the LLM as it is")
+		} else if len(redactions) == 0 {
+			opts.println("No sensitive values were found in the report")
 		} else {
-			if config.Default != helpers.Gemini && config.Default != helpers.Mistral && config.Default != helpers.Qwen {
-				utils.LogF(fmt.Sprintf("%s LLM is not supported right now. Only Gemini, Mistral and Qwen are supported at the moment", config.Default))
+			opts.printf("Redacted %d sensitive value(s) before sending the report to %s:\n", len(redactions), styles.BoldBlueTextStyle.Render(strings.Join(llmNames, ", ")))
+
+			for _, line := range helpers.SummarizeRedactions(redactions) {
+				opts.printf(">> %s\n", line)
 			}
 
-			apiKey, err := helpers.GetLlmKey(string(config.Default))
-			if err != nil {
-				utils.LogF(err.Error())
+			for i := range redactions {
+				opts.printf("   %s\n", helpers.DescribeRedaction(redactions[i]))
 			}
+		}
+	}
+
+	buildPrompt := func(llm helpers.Llm) string {
+		return helpers.BuildUxReportPrompt(llm, usePa11y, accessibilityReport, opts.persona)
+	}
 
-			llmName = string(config.Default)
-			key = apiKey
+	if dryRun || estimate {
+		estimates := helpers.EstimatePromptCosts(config, selectedLlms, fallbacks, buildPrompt)
+
+		if dryRun {
+			for _, llm := range selectedLlms {
+				opts.println(styles.BoldBlueTextStyle.Render(fmt.Sprintf("Rendered prompt for %s:", llm)))
+				opts.println(buildPrompt(llm))
+				opts.println()
+			}
 		}
 
-		var prompt string
+		opts.printf("Estimated cost of sending the prompt (assuming %d output tokens, the token counts are approximations):\n", helpers.EstimatedOutputTokens)
+
+		total := 0.0
 
-		if usePa11y {
-			if llmName == string(helpers.Gemini) {
-				prompt += `Please restructure the pa11y report into a structured format, highlighting key issues and their corresponding solutions. Employ technical language and leverage specific data from the report. In the "Additional Considerations" section, categorize recommendations based on SEO, performance, and accessibility, focusing on major and critical points. The Pa11y repot is JSON format and it contains "code", "message" and "context". Just show the structured format irrespective of whether there is a single issue. Respond in markdown. No need to re-mention the issues. Don't have any additional footer text. Don't generate a table of issues. `
-				prompt += "\n"
-				prompt += fmt.Sprintf("```\n%s```\n", accessibilityReport)
+		for i := range estimates {
+			fallback := ""
+			if estimates[i].Fallback {
+				fallback = " - fallback, only if the LLMs before it fail"
 			} else {
-				prompt += "Here is an accessibility report generated by pa11y. It is in JSON format and it contains the `code`, `message` and `context`.\n"
-				prompt += fmt.Sprintf("```\n%s```\n", accessibilityReport)
-				prompt += "Give suggestions regarding how to improve the accessibility and how to fix the errors mentioned by pa11y. Just only the solutions for those issues and also few other suggestions regarding how to improve the UX and accessiblity. Don't render a table of the JSON input. Just mention solution of every issue in a list style manner.\n"
-				prompt += "END_OF_PROMPT"
+				total += estimates[i].Cost
 			}
-		} else {
-			if llmName == string(helpers.Gemini) {
-				prompt += `Please restructure the Lighthouse report into a structured format, highlighting key issues and their corresponding solutions. Employ technical language and leverage specific data from the report. In the "Additional Considerations" section, categorize recommendations based on SEO, performance, and accessibility, focusing on major and critical points. Just show the structured format irrespective of whether there is a single issue. Respond in markdown. No need to re-mention the issues. Don't generate a table of issues. Don't have any additional footer text`
-				prompt += "\n"
-				prompt += fmt.Sprintf("```\n%s```\n", accessibilityReport)
-			} else {
-				prompt += "Here is an accessibility report generated by pa11y. It is in JSON format and it contains the `code`, `message` and `context`.\n"
-				prompt += fmt.Sprintf("```\n%s```\n", accessibilityReport)
-				prompt += "Give suggestions regarding how to improve the accessibility and how to fix the errors mentioned by pa11y. Just only the solutions for those issues and also few other suggestions regarding how to improve the UX and accessiblity. Don't render a table of the JSON input. Just mention solution of every issue in a list style manner.\n"
-				prompt += "END_OF_PROMPT"
+
+			opts.printf(">> %s - ~%d tokens (%.1f chars/token) - $%.6f%s\n", estimates[i].Llm, estimates[i].Tokens, estimates[i].CharsPerToken, estimates[i].Cost, fallback)
+		}
+
+		if len(selectedLlms) > 1 {
+			opts.println(styles.BoldBlueTextStyle.Render(fmt.Sprintf(">> total - $%.6f", total)))
+		}
+
+		if dryRun {
+			return historyEntry, ""
+		}
+
+		title := fmt.Sprintf("send the prompt to %s?", strings.Join(llmNames, ", "))
+		if len(fallbacks) != 0 {
+			var fallbackNames []string
+			for _, llm := range fallbacks {
+				fallbackNames = append(fallbackNames, string(llm))
 			}
+
+			title = fmt.Sprintf("send the prompt to %s (or to %s from the fallback chain if it fails)?", llmName, strings.Join(fallbackNames, ", "))
 		}
 
-		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
-		s.Suffix = fmt.Sprintf(" sending prompt to %s", llmName)
+		confirmed, err := helpers.ConfirmTo(opts.status, title, assumeYes)
+		if err != nil {
+			opts.fatal(err.Error())
+		}
+
+		if !confirmed {
+			opts.fatal("Cancelled sending the prompt")
+		}
+	}
+
+	var output string
+	var attempts []helpers.LlmAttempt
+
+	if compare {
+		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriterFile(opts.status))
+		s.Suffix = fmt.Sprintf(" sending prompt to %s", strings.Join(llmNames, ", "))
 		s.Start()
 
-		var output string
+		answers := helpers.QueryLlmsConcurrently(selectedLlms, keys, buildPrompt)
 
-		if llmName == string(helpers.Gemini) {
-			output, err = helpers.QueryGemini(key, prompt)
-			if err != nil {
-				utils.LogF(err.Error())
+		s.Stop()
+
+		var succeeded []helpers.LlmAnswer
+
+		for i := range answers {
+			if answers[i].Err == nil {
+				succeeded = append(succeeded, answers[i])
 			}
-		} else if llmName == string(helpers.Mistral) {
-			output, err = helpers.QueryHuggingFace(key, "mistralai/Mistral-7B-Instruct-v0.3", prompt)
+		}
+
+		if len(succeeded) == 0 {
+			opts.fatal(helpers.RenderAnswersAsMarkdown(answers))
+		}
+
+		opts.println(helpers.RenderSideBySide(answers, opts.status))
+
+		output = helpers.RenderAnswersAsMarkdown(answers)
+
+		if merge {
+			mergeLlm := config.Default
+			if !helpers.IsSupportedLlm(mergeLlm) {
+				mergeLlm = selectedLlms[0]
+			}
+
+			mergeKey, err := helpers.GetLlmKey(string(mergeLlm))
 			if err != nil {
-				utils.LogF(err.Error())
+				opts.fatal(err.Error())
 			}
 
-			output = strings.Split(output, "END_OF_PROMPT")[1]
-		} else if llmName == string(helpers.Qwen) {
-			output, err = helpers.QueryHuggingFace(key, "Qwen/Qwen2.5-72B-Instruct", prompt)
+			s := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriterFile(opts.status))
+			s.Suffix = fmt.Sprintf(" merging the answers using %s", mergeLlm)
+			s.Start()
+
+			consensus, err := helpers.QueryLlm(mergeLlm, mergeKey, helpers.BuildConsensusPrompt(mergeLlm, succeeded))
 			if err != nil {
-				utils.LogF(err.Error())
+				opts.fatal(err.Error())
 			}
 
-			output = strings.Split(output, "END_OF_PROMPT")[1]
-		} else {
-			utils.LogF("Unsupported LLM")
+			s.Stop()
+
+			output = fmt.Sprintf("# Consensus (merged by %s)\n\n%s\n\n%s", mergeLlm, strings.TrimSpace(consensus), output)
+			llmName = string(mergeLlm)
+		}
+	} else {
+		chain := append([]helpers.Llm{helpers.Llm(llmName)}, fallbacks...)
+
+		s := spinner.New(spinner.CharSets[11], 100*time.Millisecond, spinner.WithWriterFile(opts.status))
+		s.Suffix = fmt.Sprintf(" sending prompt to %s", llmName)
+		s.Start()
+
+		var usedLlm helpers.Llm
+
+		output, usedLlm, attempts, err = helpers.QueryLlmWithFallback(chain, buildPrompt, func(failed helpers.Llm, err error, next helpers.Llm) {
+			s.Suffix = fmt.Sprintf(" %s failed (%s), sending prompt to %s", failed, err.Error(), next)
+		})
+		if err != nil {
+			opts.fatal(err.Error())
 		}
 
 		s.Stop()
 
-		if !usePa11y {
-			output = fmt.Sprintf(`* **Metrics**:
-1. Score - %s
-2. First contentful paint - %s
-3. First meaningful paint - %s
-4. Largest meaningful paint - %s
-5. Speed index - %s
-6. Total blocking time - %s`, metrics[0], metrics[1], metrics[2], metrics[3], metrics[4], metrics[5]) + "\n\n" + output
+		if usedLlm != helpers.Llm(llmName) {
+			opts.printf("⚠️ %s failed, used %s from the fallback chain instead\n", llmName, styles.BoldBlueTextStyle.Render(string(usedLlm)))
 		}
 
-		homedir, _ := os.UserHomeDir()
-		now := time.Now()
-		historyDirPath := fmt.Sprintf("%s/something_history", homedir)
+		llmName = string(usedLlm)
+	}
 
-		if _, err := os.Stat(historyDirPath); os.IsNotExist(err) {
-			err := os.MkdirAll(historyDirPath, 0755)
-			if err != nil {
-				utils.LogF(err.Error())
+	if !usePa11y {
+		metrics := fmt.Sprintf(`* **Metrics**:
+1. First contentful paint - %s
+2. First meaningful paint - %s
+3. Largest meaningful paint - %s
+4. Speed index - %s
+5. Total blocking time - %s`, result.metrics[0], result.metrics[1], result.metrics[2], result.metrics[3], result.metrics[4])
+
+		n := 6
+		for i, category := range helpers.LighthouseCategories {
+			if value := result.metrics[5+i]; value != "" {
+				metrics += fmt.Sprintf("\n%d. %s score - %s", n, category, value)
+				n++
 			}
-		} else if err != nil {
-			utils.LogF(err.Error())
 		}
 
-		historyFileName := fmt.Sprintf("%s.md", now.Format("02-01-2006 15:04:05"))
-		historyFilePath := fmt.Sprintf("%s/%s", historyDirPath, historyFileName)
+		output = metrics + "\n\n" + output
+	}
 
-		if err := os.WriteFile(historyFilePath, []byte(output), 0644); err != nil {
-			utils.LogF(err.Error())
-		}
+	if redactor != nil {
+		output, _ = redactor.Redact(output)
+	}
 
-		fmt.Printf("Saved AI summary to `~/something_history/%s` file. If required, You can re-refer via that file\n", historyFileName)
+	historyEntry.Llm = helpers.Llm(llmName)
+	historyEntry.Attempts = attempts
 
-		if err := helpers.DisplayInVim(output, "markdown"); err != nil {
-			utils.LogF(err.Error())
+	if compare {
+		historyEntry.Compared = selectedLlms
+	}
+
+	if !opts.noHistory {
+		if err := helpers.SaveHistoryEntry(historyEntry, output); err != nil {
+			opts.fatal(err.Error())
 		}
+
+		opts.printf("Saved AI summary to `%s` file. If required, You can re-refer via that file or run `insightly chat %s` to ask follow-up questions\n", helpers.GetHistorySummaryFilePath(historyEntry.Id), historyEntry.Id)
+	}
+
+	if compare && !merge {
+		return historyEntry, output
 	}
+
+	doc := tui.Document{
+		Title:    fmt.Sprintf("%s summary for %s", result.tool, result.url),
+		Markdown: output,
+		Issues:   result.issues,
+		Raw:      result.report,
+		RawType:  "json",
+	}
+
+	if err := tui.DisplayTo(doc, opts.status); err != nil {
+		opts.fatal(err.Error())
+	}
+
+	return historyEntry, output
 }
@@ -7,25 +7,105 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/huggingface"
 )
 
+var HuggingFaceModels = map[Llm]string{
+	Mistral: "mistralai/Mistral-7B-Instruct-v0.3",
+	Qwen:    "Qwen/Qwen2.5-72B-Instruct",
+}
+
+var SupportedLlms = []Llm{Gemini, Mistral, Qwen}
+
+func IsSupportedLlm(llm Llm) bool {
+	for i := range SupportedLlms {
+		if SupportedLlms[i] == llm {
+			return true
+		}
+	}
+
+	return false
+}
+
 type GeminiReqPayload struct {
 	Contents []Content `json:"contents"`
 }
 
 type GeminiResp struct {
-	Candidates []Candidate `json:"candidates"`
+	Candidates     []Candidate    `json:"candidates"`
+	PromptFeedback PromptFeedback `json:"promptFeedback"`
 }
 
 type Candidate struct {
-	Content Content
+	Content      Content
+	FinishReason string `json:"finishReason"`
+}
+
+type PromptFeedback struct {
+	BlockReason string `json:"blockReason"`
+}
+
+// LlmError is returned when the LLM's API responds with an error or refuses to respond to the prompt
+type LlmError struct {
+	Llm        Llm
+	StatusCode int
+	Blocked    bool
+	Message    string
+}
+
+func (e *LlmError) Error() string {
+	if e.Blocked {
+		return fmt.Sprintf("%s blocked the prompt: %s", e.Llm, e.Message)
+	}
+
+	return fmt.Sprintf("%s responded with status code %d: %s", e.Llm, e.StatusCode, e.Message)
 }
 
+// IsRetryableLlmError reports whether the prompt could succeed with another LLM, i.e. the LLM was rate
+// limited, unavailable, timed out or blocked the prompt due to its policies
+func IsRetryableLlmError(err error) bool {
+	var llmErr *LlmError
+	if errors.As(err, &llmErr) {
+		return llmErr.Blocked || llmErr.StatusCode == http.StatusTooManyRequests || llmErr.StatusCode == http.StatusRequestTimeout || llmErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// DescribeLlmError describes the error by its kind only, e.g. `responded with status code 429`, as the
+// messages of the responses and of the network errors might carry the prompt, the URL or the key
+func DescribeLlmError(err error) string {
+	var llmErr *LlmError
+	if errors.As(err, &llmErr) {
+		if llmErr.Blocked {
+			return "blocked the prompt"
+		}
+
+		return fmt.Sprintf("responded with status code %d", llmErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timed out"
+		}
+
+		return "network error"
+	}
+
+	return "failed"
+}
+
+var huggingFaceStatusCodeRegex = regexp.MustCompile(`unexpected status code: (\d+), body: (.*)`)
+
 type Content struct {
 	Parts []Part `json:"parts"`
 }
@@ -58,7 +138,7 @@ func ParseGeminiOutput(input string) string {
 
 func QueryGemini(apiKey string, prompt string) (string, error) {
 	client := http.Client{}
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash-latest:generateContent?key=%s", apiKey)
+	url := "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash-latest:generateContent"
 
 	payload := GeminiReqPayload{
 		Contents: []Content{
@@ -82,6 +162,9 @@ func QueryGemini(apiKey string, prompt string) (string, error) {
 		return "", err
 	}
 
+	// sent as a header rather than as a query parameter, so that it doesn't end up in the errors
+	req.Header.Set("x-goog-api-key", apiKey)
+
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -94,7 +177,7 @@ func QueryGemini(apiKey string, prompt string) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.New(string(body))
+		return "", &LlmError{Llm: Gemini, StatusCode: resp.StatusCode, Message: string(body)}
 	}
 
 	var data GeminiResp
@@ -102,6 +185,19 @@ func QueryGemini(apiKey string, prompt string) (string, error) {
 		return "", err
 	}
 
+	if data.PromptFeedback.BlockReason != "" {
+		return "", &LlmError{Llm: Gemini, StatusCode: resp.StatusCode, Blocked: true, Message: data.PromptFeedback.BlockReason}
+	}
+
+	if len(data.Candidates) == 0 || len(data.Candidates[0].Content.Parts) == 0 {
+		reason := "empty response"
+		if len(data.Candidates) != 0 && data.Candidates[0].FinishReason != "" {
+			reason = data.Candidates[0].FinishReason
+		}
+
+		return "", &LlmError{Llm: Gemini, StatusCode: resp.StatusCode, Blocked: true, Message: reason}
+	}
+
 	parsedOutput := ParseGeminiOutput(data.Candidates[0].Content.Parts[0].Text)
 	return parsedOutput, nil
 }
@@ -128,3 +224,26 @@ func QueryHuggingFace(apiKey, modelName, prompt string) (string, error) {
 
 	return completion, err
 }
+
+func QueryLlm(llm Llm, apiKey, prompt string) (string, error) {
+	switch llm {
+	case Gemini:
+		return QueryGemini(apiKey, prompt)
+	case Mistral, Qwen:
+		output, err := QueryHuggingFace(apiKey, HuggingFaceModels[llm], prompt)
+		if err != nil {
+			if matches := huggingFaceStatusCodeRegex.FindStringSubmatch(err.Error()); matches != nil {
+				statusCode, _ := strconv.Atoi(matches[1])
+				return "", &LlmError{Llm: llm, StatusCode: statusCode, Message: matches[2]}
+			}
+
+			return "", err
+		}
+
+		// hugging face models echo the prompt back, the actual response starts after the marker
+		parts := strings.Split(output, "END_OF_PROMPT")
+		return parts[len(parts)-1], nil
+	default:
+		return "", fmt.Errorf("%s LLM is not supported right now", llm)
+	}
+}
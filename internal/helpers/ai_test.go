@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDescribeLlmError(t *testing.T) {
+	key := "AIzaSyA-not-a-real-key-0123456789abcdefg"
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name: "network error",
+			err: &url.Error{
+				Op:  "Post",
+				URL: "https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-flash-latest:generateContent?key=" + key,
+				Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "generativelanguage.googleapis.com"}},
+			},
+			expected: "network error",
+		},
+		{
+			name:     "rate limited",
+			err:      &LlmError{Llm: Gemini, StatusCode: 429, Message: `{"error": {"message": "quota exceeded for ` + key + `"}}`},
+			expected: "responded with status code 429",
+		},
+		{
+			name:     "blocked",
+			err:      &LlmError{Llm: Gemini, Blocked: true, Message: "SAFETY"},
+			expected: "blocked the prompt",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			description := DescribeLlmError(test.err)
+
+			if description != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, description)
+			}
+
+			if strings.Contains(description, key) {
+				t.Errorf("expected the key to be left out, got %q", description)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+type LlmAnswer struct {
+	Llm    Llm
+	Output string
+	Err    error
+}
+
+// QueryLlmsConcurrently sends the prompt returned by buildPrompt to each of the LLMs at the same time and
+// returns their answers in the same order as the given LLMs
+func QueryLlmsConcurrently(llms []Llm, keys map[Llm]string, buildPrompt func(llm Llm) string) []LlmAnswer {
+	answers := make([]LlmAnswer, len(llms))
+
+	var wg sync.WaitGroup
+
+	for i := range llms {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			output, err := QueryLlm(llms[i], keys[llms[i]], buildPrompt(llms[i]))
+			answers[i] = LlmAnswer{Llm: llms[i], Output: strings.TrimSpace(output), Err: err}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return answers
+}
+
+// RenderSideBySide renders the answers as columns which fit in the width of the terminal which they are
+// printed to
+func RenderSideBySide(answers []LlmAnswer, output *os.File) string {
+	width, _, err := term.GetSize(int(output.Fd()))
+	if err != nil || width <= 0 {
+		width = 160
+	}
+
+	columnWidth := width/len(answers) - 2
+	if columnWidth < 20 {
+		columnWidth = 20
+	}
+
+	columnStyle := lipgloss.NewStyle().Width(columnWidth).MarginRight(2)
+
+	var columns []string
+
+	for i := range answers {
+		content := answers[i].Output
+		if answers[i].Err != nil {
+			content = styles.BoldPinkTextStyle.Render(fmt.Sprintf("failed: %s", answers[i].Err.Error()))
+		}
+
+		columns = append(columns, columnStyle.Render(styles.BoldBlueTextStyle.Render(string(answers[i].Llm))+"\n\n"+content))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+}
+
+// RenderAnswersAsMarkdown renders each of the answers as a section, which is used for saving the answers
+// to history and for displaying them in the viewer
+func RenderAnswersAsMarkdown(answers []LlmAnswer) string {
+	var output strings.Builder
+
+	for i := range answers {
+		output.WriteString(fmt.Sprintf("# %s\n\n", answers[i].Llm))
+
+		if answers[i].Err != nil {
+			output.WriteString(fmt.Sprintf("Failed to get a response: %s\n\n", answers[i].Err.Error()))
+		} else {
+			output.WriteString(answers[i].Output + "\n\n")
+		}
+	}
+
+	return strings.TrimSpace(output.String())
+}
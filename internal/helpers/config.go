@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -22,46 +25,167 @@ var (
 var ValidLlms = []string{string(Gemini), string(Mistral), string(Llama), string(Claude), string(Chatgpt), string(Qwen)}
 
 type LlmConfig struct {
-	Name   Llm    `json:"name" mapstructure:"name"`
-	ApiKey string `json:"api_key" mapstructure:"api_key"`
+	Name      Llm    `json:"name" mapstructure:"name"`
+	ApiKey    string `json:"api_key,omitempty" mapstructure:"api_key"`
+	ApiKeyRef string `json:"api_key_ref,omitempty" mapstructure:"api_key_ref"`
+	ApiKeyCmd string `json:"api_key_cmd,omitempty" mapstructure:"api_key_cmd"`
 }
 
 type ConfigFile struct {
-	Default Llm         `json:"default" mapstructure:"default"`
-	Llms    []LlmConfig `json:"llms" mapstructure:"llms"`
+	Version   int                `json:"version" mapstructure:"version"`
+	Default   Llm                `json:"default" mapstructure:"default"`
+	Llms      []LlmConfig        `json:"llms" mapstructure:"llms"`
+	Fallback  []Llm              `json:"fallback,omitempty" mapstructure:"fallback"`
+	Redaction RedactionConfig    `json:"redaction,omitempty" mapstructure:"redaction"`
+	Pricing   map[Llm]LlmPricing `json:"pricing,omitempty" mapstructure:"pricing"`
+	Audit     AuditDefaults      `json:"audit,omitempty" mapstructure:"audit"`
+	// profile selected via `config profile use`, see GetProfileName
+	Profile  string             `json:"profile,omitempty" mapstructure:"profile"`
+	Profiles map[string]Profile `json:"profiles,omitempty" mapstructure:"profiles"`
+
+	// profile whose fields were swapped into the top-level fields by applyProfile
+	loadedProfile string
 }
 
 func GetConfigFilePath() string {
-	homedir, _ := os.UserHomeDir()
-	configFilePath := fmt.Sprintf("%s/.something.config.json", homedir)
-	return configFilePath
+	if configFilePathOverride != "" {
+		return configFilePathOverride
+	}
+
+	return filepath.Join(GetConfigDirPath(), "config.json")
 }
 
+// WriteToConfigFile moves the plaintext API keys to the key store (see GetKeyStore) before writing the
+// config file, so that the config file only holds references to the keys
 func WriteToConfigFile(config ConfigFile) error {
+	if _, err := SecureApiKeys(config); err != nil {
+		return err
+	}
+
+	return writeConfigFile(config)
+}
+
+func writeConfigFile(config ConfigFile) error {
+	config = storeProfile(config)
+	config.Version = CurrentConfigVersion
+
 	bytes, err := json.Marshal(&config)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(GetConfigFilePath(), bytes, 0644); err != nil {
+	if err := ensureDir(filepath.Dir(GetConfigFilePath())); err != nil {
+		return err
+	}
+	if err := backupConfigFile(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(GetConfigFilePath(), bytes, 0600); err != nil {
+		return err
+	}
+
+	// config files written by older versions were world-readable
+	if err := os.Chmod(GetConfigFilePath(), 0600); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func ReadConfigFile() (ConfigFile, error) {
-	bytes, err := os.ReadFile(GetConfigFilePath())
+// SecureApiKeys moves the plaintext API keys of the config, including the ones of its profiles, to the key
+// store and returns the number of keys which were moved
+func SecureApiKeys(config ConfigFile) (int, error) {
+	count, err := secureLlmKeys(config.Llms, func(llm Llm) string {
+		return apiKeyAccount(config, llm)
+	})
 	if err != nil {
-		return ConfigFile{}, err
+		return count, err
+	}
+
+	for name, profile := range config.Profiles {
+		n, err := secureLlmKeys(profile.Llms, func(llm Llm) string {
+			return profileApiKeyAccount(name, llm)
+		})
+
+		count += n
+
+		if err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// ReplaceLlmKey sets the API key, or the command which outputs it, of the LLM. the key which was stored
+// for it before is deleted, as a stored key or a command would otherwise take precedence over the new key
+func ReplaceLlmKey(llmConfig *LlmConfig, key string, keyCmd string) error {
+	if err := DeleteApiKeyRef(llmConfig.ApiKeyRef); err != nil {
+		return err
 	}
 
-	var configFile ConfigFile
+	*llmConfig = LlmConfig{Name: llmConfig.Name, ApiKey: key, ApiKeyCmd: keyCmd}
 
-	if err := json.Unmarshal(bytes, &configFile); err != nil {
+	return nil
+}
+
+// secureLlmKeys updates the LLM configs in place, account returns the name under which the key is stored
+func secureLlmKeys(llms []LlmConfig, account func(llm Llm) string) (int, error) {
+	count := 0
+
+	for i := range llms {
+		if llms[i].ApiKey == "" {
+			continue
+		}
+
+		ref, err := StoreApiKey(account(llms[i].Name), llms[i].ApiKey)
+		if err != nil {
+			return count, fmt.Errorf("failed to store API key of %s: %w", llms[i].Name, err)
+		}
+
+		// plaintext store
+		if ref == "" {
+			continue
+		}
+
+		llms[i].ApiKey = ""
+		llms[i].ApiKeyRef = ref
+		count++
+	}
+
+	return count, nil
+}
+
+func HasPlaintextApiKeys(config ConfigFile) bool {
+	llms := config.Llms
+	for _, profile := range config.Profiles {
+		llms = append(llms[:len(llms):len(llms)], profile.Llms...)
+	}
+
+	for i := range llms {
+		if llms[i].ApiKey != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadConfigFile reads the config file with the fields of the selected profile (see GetProfileName) as
+// its top-level fields
+func ReadConfigFile() (ConfigFile, error) {
+	configFile, applied, err := ReadRawConfigFile()
+	if err != nil {
 		return ConfigFile{}, err
 	}
 
-	return configFile, nil
+	// config files of older versions are upgraded in place, the previous version is kept as a backup
+	if len(applied) != 0 {
+		if err := writeConfigFile(configFile); err != nil {
+			return ConfigFile{}, err
+		}
+	}
+
+	return applyProfile(configFile)
 }
 
 func DoesConfigFileExists() bool {
@@ -72,17 +196,147 @@ func DoesConfigFileExists() bool {
 	return true
 }
 
-func GetLlmKey(llmName string) (string, error) {
+// LoadConfig reads the config file and adds the LLMs whose API keys are set via environment variables
+// (see GetLlmKeyEnvVar), so that commands which only read the config work without running `setup`, e.g.
+// in CI. the returned config shouldn't be written back to the config file
+func LoadConfig() (ConfigFile, error) {
 	config, err := ReadConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ConfigFile{}, err
+	}
+
+	for _, name := range ValidLlms {
+		if os.Getenv(GetLlmKeyEnvVar(Llm(name))) == "" {
+			continue
+		}
+
+		found := false
+		for i := range config.Llms {
+			if config.Llms[i].Name == Llm(name) {
+				found = true
+			}
+		}
+
+		if !found {
+			config.Llms = append(config.Llms, LlmConfig{Name: Llm(name)})
+		}
+	}
+
+	if len(config.Llms) == 0 {
+		if err == nil && config.loadedProfile != "" {
+			return ConfigFile{}, fmt.Errorf("profile %s doesn't have any LLMs. Run `setup --profile %s` to setup its LLM configuration", config.loadedProfile, config.loadedProfile)
+		}
+
+		return ConfigFile{}, err
+	}
+
+	if config.Default == "" {
+		config.Default = config.Llms[0].Name
+	}
+
+	var problems []ConfigProblem
+
+	for _, problem := range ValidateConfig(config) {
+		if !problem.Warning {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) != 0 {
+		return ConfigFile{}, &ConfigValidationError{Problems: problems}
+	}
+
+	return config, nil
+}
+
+// GetLlmKeyEnvVar returns the environment variable which can be used for passing the API key of the LLM,
+// e.g. `INSIGHTLY_GEMINI_API_KEY`
+func GetLlmKeyEnvVar(llm Llm) string {
+	return fmt.Sprintf("INSIGHTLY_%s_API_KEY", strings.ToUpper(string(llm)))
+}
+
+func GetLlmKey(llmName string) (string, error) {
+	config, err := LoadConfig()
 	if err != nil {
 		return "", err
 	}
 
 	for i := range config.Llms {
 		if config.Llms[i].Name == Llm(strings.ToLower(llmName)) {
-			return config.Llms[i].ApiKey, nil
+			return ResolveLlmKey(config.Llms[i])
 		}
 	}
 
 	return "", errors.New("invalid llm")
 }
+
+func ResolveLlmKey(llmConfig LlmConfig) (string, error) {
+	key, _, err := ResolveLlmKeyWithSource(llmConfig)
+	return key, err
+}
+
+// ResolveLlmKeyWithSource returns the API key of the LLM along with where it came from. the sources are
+// checked in the following order:
+//
+//  1. `INSIGHTLY_<LLM>_API_KEY` environment variable
+//  2. output of `api_key_cmd`
+//  3. keyring or encrypted vault, via `api_key_ref`
+//  4. plaintext `api_key`
+func ResolveLlmKeyWithSource(llmConfig LlmConfig) (string, string, error) {
+	envVar := GetLlmKeyEnvVar(llmConfig.Name)
+	if key := os.Getenv(envVar); key != "" {
+		return key, fmt.Sprintf("env %s", envVar), nil
+	}
+
+	if llmConfig.ApiKeyCmd != "" {
+		key, err := runApiKeyCmd(llmConfig.ApiKeyCmd)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to run api_key_cmd of %s: %w", llmConfig.Name, err)
+		}
+
+		return key, fmt.Sprintf("command `%s`", llmConfig.ApiKeyCmd), nil
+	}
+
+	if llmConfig.ApiKeyRef != "" {
+		key, err := ResolveApiKeyRef(llmConfig.ApiKeyRef)
+		if err != nil {
+			return "", "", err
+		}
+
+		store, _, _ := strings.Cut(llmConfig.ApiKeyRef, ":")
+		return key, store, nil
+	}
+
+	if llmConfig.ApiKey != "" {
+		return llmConfig.ApiKey, string(PlaintextStore), nil
+	}
+
+	return "", "", fmt.Errorf("no API key found for %s. Run `config set` or set %s", llmConfig.Name, envVar)
+}
+
+func runApiKeyCmd(command string) (string, error) {
+	var cmd *exec.Cmd
+
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	// tools like `pass` print the secret on the first line, followed by other metadata
+	key, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	key = strings.TrimSpace(key)
+
+	if key == "" {
+		return "", errors.New("command didn't output an API key")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,83 @@
+package helpers
+
+type MetricDelta struct {
+	Metric string  `json:"metric"`
+	Label  string  `json:"-"`
+	Base   float64 `json:"base"`
+	Head   float64 `json:"head"`
+	// change from the base to the head in percent, positive if the metric went up
+	Change float64 `json:"change"`
+	// set if the metric of the head is worse than the base by more than the tolerance
+	Regression bool `json:"regression"`
+}
+
+// RunDiff compares the head run against the base run, e.g. staging against production or a run against
+// the one before it
+type RunDiff struct {
+	Base       HistoryEntry  `json:"base"`
+	Head       HistoryEntry  `json:"head"`
+	Metrics    []MetricDelta `json:"metrics"`
+	New        []Issue       `json:"new"`
+	Fixed      []Issue       `json:"fixed"`
+	Persisting []Issue       `json:"persisting"`
+}
+
+// Regressed reports whether the head has any new issues or any metric which got worse beyond the
+// tolerance
+func (d RunDiff) Regressed() bool {
+	if len(d.New) != 0 {
+		return true
+	}
+
+	for _, delta := range d.Metrics {
+		if delta.Regression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DiffRuns compares the metrics and issues of the head run against the base run. metrics which only one
+// of the runs has are skipped
+func DiffRuns(base HistoryEntry, head HistoryEntry, tolerance float64) RunDiff {
+	diff := RunDiff{
+		Base:       base,
+		Head:       head,
+		Metrics:    []MetricDelta{},
+		New:        []Issue{},
+		Fixed:      []Issue{},
+		Persisting: []Issue{},
+	}
+
+	for _, series := range BuildTrends([]HistoryEntry{base, head}, tolerance) {
+		if len(series.Points) != 2 {
+			continue
+		}
+
+		diff.Metrics = append(diff.Metrics, MetricDelta{
+			Metric:     series.Metric,
+			Label:      series.Label,
+			Base:       series.Points[0].Value,
+			Head:       series.Points[1].Value,
+			Change:     series.Points[1].Change,
+			Regression: series.Points[1].Regression,
+		})
+	}
+
+	matches, unmatched := matchIssues(base.Issues, head.Issues)
+
+	for i, issue := range head.Issues {
+		if matches[i] == -1 {
+			diff.New = append(diff.New, issue)
+		} else {
+			diff.Persisting = append(diff.Persisting, issue)
+		}
+	}
+
+	for _, i := range unmatched {
+		diff.Fixed = append(diff.Fixed, base.Issues[i])
+	}
+
+	return diff
+}
@@ -0,0 +1,82 @@
+package helpers
+
+// number of output tokens assumed while estimating the cost of a prompt, as the actual
+// length of the response can't be known before sending it
+const EstimatedOutputTokens = 1024
+
+type LlmPricing struct {
+	InputPerMillion  float64 `json:"input_per_million" mapstructure:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million" mapstructure:"output_per_million"`
+}
+
+// prices are in USD per million tokens. mistral, qwen and llama are queried via the free hugging face
+// inference API, so they don't cost anything by default
+var DefaultLlmPricing = map[Llm]LlmPricing{
+	Gemini:  {InputPerMillion: 0.075, OutputPerMillion: 0.30},
+	Mistral: {InputPerMillion: 0, OutputPerMillion: 0},
+	Qwen:    {InputPerMillion: 0, OutputPerMillion: 0},
+	Llama:   {InputPerMillion: 0, OutputPerMillion: 0},
+	Claude:  {InputPerMillion: 3, OutputPerMillion: 15},
+	Chatgpt: {InputPerMillion: 2.5, OutputPerMillion: 10},
+}
+
+// average number of characters per token of the tokenizers of the LLMs. the tokenizers themselves aren't
+// available offline, so the token counts are approximations
+var approximateCharsPerToken = map[Llm]float64{
+	Gemini:  4,
+	Mistral: 3.5,
+	Qwen:    3.8,
+	Llama:   3.8,
+	Claude:  3.5,
+}
+
+type PromptEstimate struct {
+	Llm Llm
+	// approximate, see ApproximatePromptTokens
+	Tokens        int
+	CharsPerToken float64
+	Cost          float64
+	// set for the LLMs of the fallback chain, which are only sent the prompt if the LLMs before them fail
+	Fallback bool
+}
+
+// ApproximatePromptTokens approximates the number of tokens in the prompt for the given LLM from the
+// average number of characters per token of its tokenizer, which is returned along with it
+func ApproximatePromptTokens(llm Llm, prompt string) (int, float64) {
+	charsPerToken, ok := approximateCharsPerToken[llm]
+	if !ok {
+		charsPerToken = 4
+	}
+
+	return int(float64(len([]rune(prompt)))/charsPerToken + 0.5), charsPerToken
+}
+
+func GetLlmPricing(config ConfigFile, llm Llm) LlmPricing {
+	if pricing, ok := config.Pricing[llm]; ok {
+		return pricing
+	}
+
+	return DefaultLlmPricing[llm]
+}
+
+// EstimatePromptCosts estimates the number of tokens and the cost of sending the prompt to each of the
+// given LLMs, followed by the fallback LLMs which it might be sent to. the prompt is built for each of
+// them, as it differs between the LLMs
+func EstimatePromptCosts(config ConfigFile, llms []Llm, fallbacks []Llm, buildPrompt func(llm Llm) string) []PromptEstimate {
+	var estimates []PromptEstimate
+
+	for i, llm := range append(append([]Llm{}, llms...), fallbacks...) {
+		tokens, charsPerToken := ApproximatePromptTokens(llm, buildPrompt(llm))
+		pricing := GetLlmPricing(config, llm)
+
+		estimates = append(estimates, PromptEstimate{
+			Llm:           llm,
+			Tokens:        tokens,
+			CharsPerToken: charsPerToken,
+			Cost:          (float64(tokens)*pricing.InputPerMillion + float64(EstimatedOutputTokens)*pricing.OutputPerMillion) / 1_000_000,
+			Fallback:      i >= len(llms),
+		})
+	}
+
+	return estimates
+}
@@ -0,0 +1,76 @@
+package helpers
+
+import "fmt"
+
+type LlmAttempt struct {
+	Llm   Llm    `json:"llm"`
+	Error string `json:"error"`
+}
+
+// GetFallbackChain returns the LLMs which are tried in order for the prompt, starting with the selected
+// LLM followed by the fallback LLMs from the config file which are supported and configured
+func GetFallbackChain(config ConfigFile, selected Llm) []Llm {
+	chain := []Llm{selected}
+
+	for _, llm := range config.Fallback {
+		if llm == selected || !IsSupportedLlm(llm) {
+			continue
+		}
+
+		configured := false
+		for i := range config.Llms {
+			if config.Llms[i].Name == llm {
+				configured = true
+			}
+		}
+
+		duplicate := false
+		for i := range chain {
+			if chain[i] == llm {
+				duplicate = true
+			}
+		}
+
+		if configured && !duplicate {
+			chain = append(chain, llm)
+		}
+	}
+
+	return chain
+}
+
+// QueryLlmWithFallback sends the prompt to each LLM of the chain in order, until one of them responds.
+// it only moves on to the next LLM if the error is retryable (see IsRetryableLlmError) and returns the
+// LLM which responded along with the failed attempts. the errors of the attempts are described by their
+// kind only (see DescribeLlmError), as they are saved to history
+func QueryLlmWithFallback(chain []Llm, buildPrompt func(llm Llm) string, onFallback func(failed Llm, err error, next Llm)) (string, Llm, []LlmAttempt, error) {
+	var attempts []LlmAttempt
+
+	for i, llm := range chain {
+		key, err := GetLlmKey(string(llm))
+		if err != nil {
+			return "", llm, attempts, err
+		}
+
+		output, err := QueryLlm(llm, key, buildPrompt(llm))
+		if err == nil {
+			return output, llm, attempts, nil
+		}
+
+		attempts = append(attempts, LlmAttempt{Llm: llm, Error: DescribeLlmError(err)})
+
+		if !IsRetryableLlmError(err) || i == len(chain)-1 {
+			if len(attempts) > 1 {
+				return "", llm, attempts, fmt.Errorf("all the LLMs in the fallback chain failed, last error: %w", err)
+			}
+
+			return "", llm, attempts, err
+		}
+
+		if onFallback != nil {
+			onFallback(llm, err, chain[i+1])
+		}
+	}
+
+	return "", "", attempts, fmt.Errorf("no LLMs to send the prompt to")
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"golang.org/x/term"
+)
+
+var ErrNotInteractive = errors.New("stdin is not a terminal, so the interactive form can't be opened. Use the command's flags instead (see `--help`)")
+
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// RunForm runs the form only if stdin is a terminal, as huh forms hang or fail in scripts and CI
+func RunForm(form *huh.Form) error {
+	if !IsInteractive() {
+		return ErrNotInteractive
+	}
+
+	return form.Run()
+}
+
+// Confirm asks the user to confirm the action, unless assumeYes is set (i.e. `--yes` was passed)
+func Confirm(title string, assumeYes bool) (bool, error) {
+	return ConfirmTo(os.Stdout, title, assumeYes)
+}
+
+// ConfirmTo is Confirm which renders the prompt to the given writer, e.g. stderr when stdout is piped
+func ConfirmTo(output io.Writer, title string, assumeYes bool) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+
+	if !IsInteractive() {
+		return false, errors.New("stdin is not a terminal, so the confirmation prompt can't be opened. Pass `--yes` to confirm")
+	}
+
+	confirmed := false
+
+	if err := huh.NewForm(huh.NewGroup(huh.NewConfirm().Title(title).Value(&confirmed))).WithOutput(output).Run(); err != nil {
+		return false, err
+	}
+
+	return confirmed, nil
+}
+
+// ReadKeyFromStdin reads the API key piped via stdin, e.g. `echo $KEY | insightly setup --key-stdin`
+func ReadKeyFromStdin() (string, error) {
+	bytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(string(bytes))
+	if key == "" {
+		return "", errors.New("no API key was passed via stdin")
+	}
+
+	return key, nil
+}
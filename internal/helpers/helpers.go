@@ -1,11 +1,10 @@
 package helpers
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -32,12 +31,20 @@ func IsLighthouseInstalled() bool {
 
 type Pa11yOutputErr struct {
 	Code    string `json:"code"`
+	Type    string `json:"type"`
 	Message string `json:"message"`
 	Context string `json:"context"`
+	// css selector of the element which has the issue
+	Selector string `json:"selector"`
 }
 
-func GeneratePa11yReport(website string) ([]Pa11yOutputErr, error) {
-	cmd := exec.Command("pa11y", website, "--reporter", "json")
+func GeneratePa11yReport(website string, standard string) ([]Pa11yOutputErr, error) {
+	args := []string{website, "--reporter", "json"}
+	if standard != "" {
+		args = append(args, "--standard", standard)
+	}
+
+	cmd := exec.Command("pa11y", args...)
 	output, err := cmd.Output()
 
 	if err != nil && !strings.Contains(err.Error(), "exit status 2") {
@@ -54,17 +61,31 @@ func GeneratePa11yReport(website string) ([]Pa11yOutputErr, error) {
 
 type LighthouseAudit struct {
 	Id           string   `json:"id"`
+	Title        string   `json:"title"`
 	Score        *float64 `json:"score"`
 	NumericValue float64  `json:"numericValue"`
 	NumericUnit  string   `json:"numericUnit"`
+	// binary, numeric, metricSavings, manual, notApplicable, informative or error
+	ScoreDisplayMode string `json:"scoreDisplayMode,omitempty"`
 }
+
+// keys of the lighthouse metrics in the parsed report and in history. the scores of the categories come
+// last, in the order of LighthouseCategories
+var LighthouseMetrics = []string{"first_contentful_paint", "first_meaningful_paint", "largest_contentful_paint", "speed_index", "total_blocking_time", "performance", "accessibility", "best_practices", "seo", "pwa"}
+
 type LighthouseReport struct {
-	Audits map[string]LighthouseAudit `json:"audits"`
+	Audits     map[string]LighthouseAudit    `json:"audits"`
+	Categories map[string]LighthouseCategory `json:"categories"`
+	// data URI of the screenshot of the page once it finished loading
+	FinalScreenshot string `json:"-"`
 }
 
 func GenerateLighthouseReport(website string) (LighthouseReport, error) {
-	homedir, _ := os.UserHomeDir()
-	tmpLighthouseReportFilePath := fmt.Sprintf("%s/.something.lighthouse.tmp.json", homedir)
+	if err := ensureDir(GetCacheDirPath()); err != nil {
+		return LighthouseReport{}, err
+	}
+
+	tmpLighthouseReportFilePath := filepath.Join(GetCacheDirPath(), "lighthouse.tmp.json")
 
 	go func() {
 		cmd := exec.Command("lighthouse", website,
@@ -103,43 +124,20 @@ func GenerateLighthouseReport(website string) (LighthouseReport, error) {
 		return LighthouseReport{}, err
 	}
 
-	return lighthouseReport, nil
-}
+	// the details of the audits aren't parsed along with them, as they are only needed for the screenshot
+	var finalScreenshot struct {
+		Audits struct {
+			FinalScreenshot struct {
+				Details struct {
+					Data string `json:"data"`
+				} `json:"details"`
+			} `json:"final-screenshot"`
+		} `json:"audits"`
+	}
 
-func DisplayInVim(content string, filetype string) error {
-	vimCommands := strings.Join([]string{
-		"set noswapfile",
-		"set number",
-		"syntax on",
-		fmt.Sprintf("set ft=%s", filetype),
-		"set autoindent",
-		"set conceallevel=2",
-		"set background=dark",
-		"colorscheme industry",
-		"set expandtab",
-		"set tabstop=2",
-		"set shiftwidth=2",
-		"set wrap",
-		"set linebreak",
-		"set breakindent",
-		"hi link markdownError NONE",
-		"set cole=2",
-		"hi markdownH1 cterm=bold ctermfg=blue",
-		"hi markdownH2 cterm=bold ctermfg=cyan",
-		"hi markdownLinkText cterm=underline ctermfg=green",
-		"hi markdownUrl cterm=underline ctermfg=green",
-		"hi markdownCode ctermfg=yellow",
-		"hi markdownCodeBlock ctermfg=yellow",
-	}, " | ")
-
-	cmd := exec.Command("vim", "-c", vimCommands, "-")
-	cmd.Stdin = bytes.NewBufferString(content)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return err
+	if err := json.Unmarshal(lighthouseReportBytes, &finalScreenshot); err == nil {
+		lighthouseReport.FinalScreenshot = finalScreenshot.Audits.FinalScreenshot.Details.Data
 	}
 
-	return nil
+	return lighthouseReport, nil
 }
@@ -0,0 +1,476 @@
+package helpers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// format of the history entry ids, which are also used as the directory names of the entries
+const HistoryIdFormat = "2006-01-02_15-04-05"
+
+// format of the file names of the summaries saved by the very first versions, `~/something_history/<id>.md`
+const legacyHistoryIdFormat = "02-01-2006 15:04:05"
+
+// HistoryEntry is a single run of an auditor against a URL. each entry is stored in its own directory:
+//
+//	<id>/entry.json  - metadata along with the normalized issues
+//	<id>/report.json - raw report of the auditor
+//	<id>/summary.md  - AI summary, only if the run used `--use-ai`
+//	<id>/screenshot.jpg - final screenshot of the page, only for lighthouse runs
+//	<id>/chat.json   - chat transcript, see WriteChatTranscript
+type HistoryEntry struct {
+	Id       string       `json:"id"`
+	Url      string       `json:"url"`
+	Tool     string       `json:"tool"`
+	Llm      Llm          `json:"llm,omitempty"`
+	Compared []Llm        `json:"compared,omitempty"`
+	Attempts []LlmAttempt `json:"failed_attempts,omitempty"`
+	// performance score of lighthouse runs, out of 100 as reported by lighthouse. the scores of the other
+	// categories are in Metrics
+	Score   *float64          `json:"score,omitempty"`
+	Metrics map[string]string `json:"metrics,omitempty"`
+	Issues  []Issue           `json:"issues"`
+	// issues which were left out of the run as they match the `ignore` of the project config, or were
+	// accepted or marked as false positives during triage (see Issue.Triage). they are still tracked, so
+	// that leaving an issue out doesn't mark it as fixed
+	Ignored []Issue `json:"ignored,omitempty"`
+	// issues of the previous run of the URL which this run no longer has
+	Fixed []Issue `json:"fixed,omitempty"`
+	// id of the previous run of the URL by the same auditor
+	Previous  string    `json:"previous,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// raw report, which is saved as report.json
+	Report string `json:"-"`
+	// data URI of the final screenshot of lighthouse runs, which is saved as screenshot.<ext>
+	Screenshot string `json:"-"`
+}
+
+// HistoryIndexEntry is what's kept in the database for listing and searching the entries without reading
+// each one of them
+type HistoryIndexEntry struct {
+	Id         string    `json:"id"`
+	Url        string    `json:"url"`
+	Tool       string    `json:"tool"`
+	Llm        Llm       `json:"llm,omitempty"`
+	Score      *float64  `json:"score,omitempty"`
+	Issues     int       `json:"issues"`
+	HasSummary bool      `json:"has_summary"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ChatTurn struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func GetHistoryDirPath() string {
+	return filepath.Join(GetDataDirPath(), "history")
+}
+
+func GetHistoryEntryDirPath(id string) string {
+	return filepath.Join(GetHistoryDirPath(), id)
+}
+
+func GetHistorySummaryFilePath(id string) string {
+	return filepath.Join(GetHistoryEntryDirPath(id), "summary.md")
+}
+
+func GetChatTranscriptFilePath(id string) string {
+	return filepath.Join(GetHistoryEntryDirPath(id), "chat.md")
+}
+
+// NewHistoryId returns an id based on the time, with a numeric suffix if an entry with the same id
+// already exists, e.g. when multiple URLs are audited in the same second
+func NewHistoryId(now time.Time) string {
+	base := now.Format(HistoryIdFormat)
+	id := base
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(GetHistoryEntryDirPath(id)); errors.Is(err, os.ErrNotExist) {
+			return id
+		}
+
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func ensureHistoryDir() error {
+	return ensureDir(GetHistoryDirPath())
+}
+
+// SaveHistoryEntry saves the entry and stores it in the database. the summary is only written if it isn't empty,
+// so that the summary can be added to an entry which was saved before sending the report to the LLM
+func SaveHistoryEntry(entry HistoryEntry, summary string) error {
+	if err := ensureHistoryStore(); err != nil {
+		return err
+	}
+
+	dirPath := GetHistoryEntryDirPath(entry.Id)
+
+	if err := ensureDir(dirPath); err != nil {
+		return err
+	}
+
+	if entry.Issues == nil {
+		entry.Issues = []Issue{}
+	}
+
+	bytes, err := json.MarshalIndent(&entry, "", " ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dirPath, "entry.json"), bytes, 0600); err != nil {
+		return err
+	}
+
+	if entry.Report != "" {
+		if err := os.WriteFile(filepath.Join(dirPath, "report.json"), []byte(entry.Report), 0600); err != nil {
+			return err
+		}
+	}
+
+	if entry.Screenshot != "" {
+		if err := writeScreenshot(dirPath, entry.Screenshot); err != nil {
+			return err
+		}
+	}
+
+	if summary != "" {
+		if err := os.WriteFile(GetHistorySummaryFilePath(entry.Id), []byte(summary), 0600); err != nil {
+			return err
+		}
+	}
+
+	// the summary might've been saved along with an earlier version of the entry
+	savedSummary, err := os.ReadFile(GetHistorySummaryFilePath(entry.Id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return updateStore(func(tx *sql.Tx) error {
+		return storeHistoryEntry(tx, entry, string(savedSummary))
+	})
+}
+
+// ReadHistoryEntry reads the history entry along with its AI summary, which is empty if the run didn't
+// use `--use-ai`. the id can also be `latest` or a unique prefix of an id
+func ReadHistoryEntry(id string) (HistoryEntry, string, error) {
+	id, err := ResolveHistoryId(id)
+	if err != nil {
+		return HistoryEntry{}, "", err
+	}
+
+	return readHistoryEntry(id)
+}
+
+func readHistoryEntry(id string) (HistoryEntry, string, error) {
+	dirPath := GetHistoryEntryDirPath(id)
+
+	bytes, err := os.ReadFile(filepath.Join(dirPath, "entry.json"))
+	if err != nil {
+		return HistoryEntry{}, "", err
+	}
+
+	var entry HistoryEntry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return HistoryEntry{}, "", fmt.Errorf("history entry %s is corrupted: %w", id, err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(dirPath, "report.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return HistoryEntry{}, "", err
+	}
+
+	entry.Report = string(report)
+
+	entry.Screenshot, err = readScreenshot(dirPath)
+	if err != nil {
+		return HistoryEntry{}, "", err
+	}
+
+	summary, err := os.ReadFile(GetHistorySummaryFilePath(id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return HistoryEntry{}, "", err
+	}
+
+	return entry, string(summary), nil
+}
+
+// ListHistoryEntries returns the stored entries, the most recent one first
+func ListHistoryEntries() ([]HistoryIndexEntry, error) {
+	if err := ensureHistoryStore(); err != nil {
+		return nil, err
+	}
+
+	return listStoredHistoryEntries("")
+}
+
+// ResolveHistoryId returns the id of the entry which is referred to by `latest`, the full id or a unique
+// prefix of it
+func ResolveHistoryId(id string) (string, error) {
+	entries, err := ListHistoryEntries()
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 0 {
+		return "", errors.New("no history entries found. Run `gen-ux` to generate one")
+	}
+
+	if id == "latest" {
+		return entries[0].Id, nil
+	}
+
+	var matches []string
+
+	for i := range entries {
+		if entries[i].Id == id {
+			return id, nil
+		}
+
+		if strings.HasPrefix(entries[i].Id, id) {
+			matches = append(matches, entries[i].Id)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no history entry found with id %s. Run `history list` to list the entries", id)
+	}
+
+	if len(matches) > 1 {
+		return "", fmt.Errorf("%s matches multiple history entries: %s", id, strings.Join(matches, ", "))
+	}
+
+	return matches[0], nil
+}
+
+// normalizeHistoryUrl lets `example.com`, `https://example.com/` and `https://EXAMPLE.com` be treated as
+// the same URL
+func normalizeHistoryUrl(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+
+	for _, scheme := range []string{"https://", "http://"} {
+		url = strings.TrimPrefix(url, scheme)
+	}
+
+	url = strings.TrimPrefix(url, "www.")
+
+	return strings.TrimSuffix(url, "/")
+}
+
+// FindLatestHistoryEntry returns the most recent run of the URL by the auditor, see normalizeHistoryUrl
+// for how the URLs are matched
+func FindLatestHistoryEntry(url string, tool string) (HistoryEntry, error) {
+	entries, err := ListHistoryEntries()
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+
+	for _, entry := range entries {
+		if entry.Tool == tool && normalizeHistoryUrl(entry.Url) == normalizeHistoryUrl(url) {
+			historyEntry, _, err := readHistoryEntry(entry.Id)
+			return historyEntry, err
+		}
+	}
+
+	return HistoryEntry{}, fmt.Errorf("no %s runs of %s found in history", tool, url)
+}
+
+// SearchHistory returns the entries whose URL, tool, LLM, open issues or summary contain the query,
+// ignoring the case
+func SearchHistory(query string) ([]HistoryIndexEntry, error) {
+	if err := ensureHistoryStore(); err != nil {
+		return nil, err
+	}
+
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+
+	return listStoredHistoryEntries(
+		`id || ' ' || url || ' ' || tool || ' ' || llm || ' ' || summary LIKE ? ESCAPE '\'
+		OR id IN (SELECT run_id FROM issues WHERE status != 'fixed' AND rule || ' ' || message || ' ' || selector LIKE ? ESCAPE '\')`,
+		pattern, pattern,
+	)
+}
+
+// DeleteHistoryEntry removes the entry along with its report, summary and chat transcript
+func DeleteHistoryEntry(id string) error {
+	if err := os.RemoveAll(GetHistoryEntryDirPath(id)); err != nil {
+		return err
+	}
+
+	return updateStore(func(tx *sql.Tx) error {
+		if err := deleteStoredHistoryEntry(tx, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("DELETE FROM pages WHERE id NOT IN (SELECT page_id FROM runs)")
+		return err
+	})
+}
+
+// SelectHistoryEntriesToPrune returns the entries which are older than olderThan (if it isn't zero) or
+// aren't among the keep most recent entries (if keep isn't negative). entries must be sorted by
+// ListHistoryEntries
+func SelectHistoryEntriesToPrune(entries []HistoryIndexEntry, olderThan time.Duration, keep int) []HistoryIndexEntry {
+	var pruned []HistoryIndexEntry
+
+	for i := range entries {
+		if keep >= 0 && i >= keep {
+			pruned = append(pruned, entries[i])
+			continue
+		}
+
+		if olderThan != 0 && time.Since(entries[i].CreatedAt) > olderThan {
+			pruned = append(pruned, entries[i])
+		}
+	}
+
+	return pruned
+}
+
+// ensureHistoryStore rebuilds the database from the entries if it is missing
+func ensureHistoryStore() error {
+	if _, err := os.Stat(GetStoreFilePath()); err == nil {
+		return nil
+	}
+
+	return RebuildHistoryStore()
+}
+
+// migrateLegacySummary moves a summary saved by older versions (which only kept the summary, named
+// after the time it was saved at) into the directory of a new entry
+func migrateLegacySummary(path string) error {
+	summary, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	createdAt, err := time.ParseInLocation(legacyHistoryIdFormat, strings.TrimSuffix(filepath.Base(path), ".md"), time.Local)
+	if err != nil {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		createdAt = info.ModTime()
+	}
+
+	entry := HistoryEntry{
+		Id:        NewHistoryId(createdAt),
+		CreatedAt: createdAt,
+		Issues:    []Issue{},
+	}
+
+	if err := ensureDir(GetHistoryEntryDirPath(entry.Id)); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(&entry, "", " ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(GetHistoryEntryDirPath(entry.Id), "entry.json"), bytes, 0600); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(GetHistorySummaryFilePath(entry.Id), summary, 0600); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func ReadChatTranscript(id string) ([]ChatTurn, error) {
+	bytes, err := os.ReadFile(filepath.Join(GetHistoryEntryDirPath(id), "chat.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var turns []ChatTurn
+	if err := json.Unmarshal(bytes, &turns); err != nil {
+		return nil, err
+	}
+
+	return turns, nil
+}
+
+// WriteChatTranscript saves the chat transcript in the directory of the history entry, both as JSON
+// (which is used for resuming the chat) and as markdown for reading it later on
+func WriteChatTranscript(id string, turns []ChatTurn) error {
+	if err := ensureDir(GetHistoryEntryDirPath(id)); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(&turns, "", " ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(GetHistoryEntryDirPath(id), "chat.json"), bytes, 0600); err != nil {
+		return err
+	}
+
+	var transcript strings.Builder
+
+	for i := range turns {
+		transcript.WriteString(fmt.Sprintf("## %s (%s)\n\n%s\n\n", turns[i].Role, turns[i].CreatedAt.Format(time.RFC1123), strings.TrimSpace(turns[i].Content)))
+	}
+
+	return os.WriteFile(GetChatTranscriptFilePath(id), []byte(transcript.String()), 0600)
+}
+
+var screenshotExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// writeScreenshot decodes the data URI of the screenshot and saves it in the directory of the history
+// entry, so that it can be opened like any other image
+func writeScreenshot(dirPath string, dataUri string) error {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(dataUri, "data:"), ",")
+	mimeType, encoding, _ := strings.Cut(meta, ";")
+
+	extension, supported := screenshotExtensions[mimeType]
+	if !ok || !supported || encoding != "base64" {
+		return fmt.Errorf("unsupported screenshot %.32s...", dataUri)
+	}
+
+	bytes, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("invalid screenshot: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(dirPath, "screenshot."+extension), bytes, 0600)
+}
+
+// readScreenshot returns the screenshot saved in the directory of the history entry as a data URI, or an
+// empty string if there is none
+func readScreenshot(dirPath string) (string, error) {
+	for mimeType, extension := range screenshotExtensions {
+		bytes, err := os.ReadFile(filepath.Join(dirPath, "screenshot."+extension))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(bytes)), nil
+	}
+
+	return "", nil
+}
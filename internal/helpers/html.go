@@ -0,0 +1,249 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// circumference of the score gauge, whose radius is 40
+const htmlGaugeCircumference = 2 * math.Pi * 40
+
+type htmlReportRun struct {
+	Id         string
+	Url        string
+	Tool       string
+	CreatedAt  string
+	HasScore   bool
+	Score      float64
+	Gauge      string
+	Rating     string
+	Issues     int
+	Severities map[string]int
+	Metrics    [][2]string
+	Screenshot template.URL
+	Summary    template.HTML
+}
+
+type htmlReport struct {
+	Title       string
+	GeneratedAt string
+	Runs        []htmlReportRun
+	Criteria    []reportCriterion
+}
+
+// RenderHtmlReport renders the runs as a single HTML file, which has everything inlined (styles, the
+// screenshots and the summaries) so that it can be opened offline or attached to a ticket
+func RenderHtmlReport(runs []ReportRun) ([]byte, error) {
+	report := htmlReport{
+		Title:       reportTitle(runs),
+		GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+		Criteria:    groupIssuesByCriterion(runs),
+	}
+
+	markdown := goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	for _, run := range runs {
+		entry := run.Entry
+
+		htmlRun := htmlReportRun{
+			Id:         entry.Id,
+			Url:        entry.Url,
+			Tool:       entry.Tool,
+			CreatedAt:  entry.CreatedAt.Local().Format("2006-01-02 15:04"),
+			Issues:     len(entry.Issues),
+			Severities: map[string]int{},
+		}
+
+		if entry.Score != nil {
+			htmlRun.HasScore = true
+			htmlRun.Score = *entry.Score
+			htmlRun.Gauge = fmt.Sprintf("%.2f %.2f", *entry.Score/100*htmlGaugeCircumference, htmlGaugeCircumference)
+			htmlRun.Rating = scoreRating(*entry.Score)
+		}
+
+		for _, metric := range LighthouseMetrics {
+			if value, ok := entry.Metrics[metric]; ok {
+				htmlRun.Metrics = append(htmlRun.Metrics, [2]string{strings.ReplaceAll(metric, "_", " "), value})
+			}
+		}
+
+		// only images are embedded, so that a tampered report can't smuggle anything else into the page
+		if strings.HasPrefix(entry.Screenshot, "data:image/") {
+			htmlRun.Screenshot = template.URL(entry.Screenshot)
+		}
+
+		if run.Summary != "" {
+			var buffer bytes.Buffer
+
+			// raw HTML in the summary is escaped, as goldmark only renders it if it is explicitly allowed
+			if err := markdown.Convert([]byte(run.Summary), &buffer); err != nil {
+				return nil, err
+			}
+
+			htmlRun.Summary = template.HTML(buffer.String())
+		}
+
+		for _, issue := range entry.Issues {
+			htmlRun.Severities[issue.Severity]++
+		}
+
+		report.Runs = append(report.Runs, htmlRun)
+	}
+
+	var buffer bytes.Buffer
+
+	if err := htmlReportTemplate.Execute(&buffer, report); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// scoreRating follows the colors of lighthouse, i.e. 90 and above is good and below 50 is poor
+func scoreRating(score float64) string {
+	switch {
+	case score >= 90:
+		return "good"
+	case score >= 50:
+		return "average"
+	default:
+		return "poor"
+	}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+:root { --fg: #1f2328; --muted: #656d76; --border: #d0d7de; --bg: #f6f8fa; --good: #0c7d3a; --average: #c76b00; --poor: #cf222e; }
+* { box-sizing: border-box; }
+body { margin: 0; font: 15px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: var(--fg); }
+main { max-width: 1100px; margin: 0 auto; padding: 32px 24px; }
+h1 { margin: 0 0 4px; font-size: 26px; }
+h2 { margin: 40px 0 16px; padding-bottom: 6px; border-bottom: 1px solid var(--border); font-size: 20px; }
+h3 { margin: 0 0 8px; font-size: 16px; word-break: break-all; }
+a { color: #0969da; }
+.muted { color: var(--muted); }
+.runs { display: grid; grid-template-columns: repeat(auto-fill, minmax(240px, 1fr)); gap: 16px; margin-top: 24px; }
+.card { border: 1px solid var(--border); border-radius: 8px; padding: 16px; }
+.gauge { display: block; margin: 8px auto; }
+.gauge text { font-size: 22px; font-weight: 600; }
+.good { color: var(--good); stroke: var(--good); fill: var(--good); }
+.average { color: var(--average); stroke: var(--average); fill: var(--average); }
+.poor { color: var(--poor); stroke: var(--poor); fill: var(--poor); }
+.tile { text-align: center; font-size: 40px; font-weight: 600; margin: 8px 0; }
+table { border-collapse: collapse; width: 100%; margin: 8px 0; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid var(--border); vertical-align: top; }
+th { background: var(--bg); }
+.screenshot { max-width: 100%; max-height: 640px; border: 1px solid var(--border); border-radius: 8px; }
+.summary { border: 1px solid var(--border); border-radius: 8px; padding: 0 20px; margin-bottom: 16px; }
+details { border: 1px solid var(--border); border-radius: 8px; margin-bottom: 12px; }
+details > summary { cursor: pointer; padding: 10px 16px; font-weight: 600; background: var(--bg); border-radius: 8px; }
+details > div { padding: 8px 16px 16px; }
+.issue { border-top: 1px solid var(--border); padding: 10px 0; }
+.issue:first-of-type { border-top: 0; }
+.badge { display: inline-block; padding: 0 8px; border-radius: 10px; font-size: 12px; font-weight: 600; color: #fff; background: var(--muted); }
+.badge.error { background: var(--poor); }
+.badge.warning { background: var(--average); }
+.badge.notice { background: #0969da; }
+.badge.outline { color: var(--fg); background: transparent; border: 1px solid var(--border); }
+code, pre { font: 13px/1.4 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+pre { background: var(--bg); padding: 10px; border-radius: 6px; overflow-x: auto; white-space: pre-wrap; word-break: break-all; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<div class="muted">Generated by insightly on {{.GeneratedAt}}</div>
+
+<div class="runs">
+{{- range .Runs}}
+<div class="card">
+<h3><a href="{{.Url}}">{{.Url}}</a></h3>
+<div class="muted">{{.Tool}} &middot; {{.CreatedAt}} &middot; {{.Id}}</div>
+{{- if .HasScore}}
+<svg class="gauge {{.Rating}}" width="120" height="120" viewBox="0 0 100 100" role="img" aria-label="Performance score {{printf "%.0f" .Score}} out of 100">
+<circle cx="50" cy="50" r="40" fill="none" stroke="#d0d7de" stroke-width="8"/>
+<circle cx="50" cy="50" r="40" fill="none" stroke-width="8" stroke-linecap="round" stroke-dasharray="{{.Gauge}}" transform="rotate(-90 50 50)"/>
+<text x="50" y="50" text-anchor="middle" dominant-baseline="central" stroke="none">{{printf "%.0f" .Score}}</text>
+</svg>
+<div class="muted" style="text-align: center">performance</div>
+{{- else}}
+<div class="tile">{{.Issues}}</div>
+<div class="muted" style="text-align: center">issue(s)</div>
+{{- end}}
+<div style="text-align: center">
+{{- range $severity, $count := .Severities}} <span class="badge {{$severity}}">{{$count}} {{$severity}}</span>{{end}}
+</div>
+</div>
+{{- end}}
+</div>
+
+{{- range .Runs}}
+{{- if or .Metrics .Screenshot}}
+<h2>Metrics of {{.Url}}</h2>
+{{- if .Metrics}}
+<table>
+<tr><th>Metric</th><th>Value</th></tr>
+{{- range .Metrics}}
+<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Screenshot}}
+<p><img class="screenshot" src="{{.Screenshot}}" alt="Final screenshot of {{.Url}}"></p>
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- range .Runs}}
+{{- if .Summary}}
+<h2>AI summary of the {{.Tool}} report for {{.Url}}</h2>
+<div class="summary">
+{{.Summary}}
+</div>
+{{- end}}
+{{- end}}
+
+<h2>Issues</h2>
+{{- range .Criteria}}
+<details open>
+<summary>{{.Title}} <span class="badge outline">{{.Issues}}</span></summary>
+<div>
+{{- range .Pages}}
+<h3>{{.Url}}</h3>
+{{- range .Issues}}
+<div class="issue">
+<span class="badge {{.Severity}}">{{.Severity}}</span> <span class="badge outline">{{.Tool}}</span>
+{{- if .Triage}} <span class="badge outline">{{.Triage}}</span>{{end}}
+{{- if .Owner}} <span class="badge outline">@{{.Owner}}</span>{{end}}
+<div>{{.Message}}</div>
+<div class="muted"><code>{{.Rule}}</code></div>
+{{- if .Selector}}
+<div>Selector: <code>{{.Selector}}</code></div>
+{{- end}}
+{{- if .Context}}
+<pre><code>{{.Context}}</code></pre>
+{{- end}}
+</div>
+{{- end}}
+{{- end}}
+</div>
+</details>
+{{- else}}
+<p class="muted">No issues were found.</p>
+{{- end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,98 @@
+package helpers
+
+import "time"
+
+// Issue is a problem found by one of the auditors, normalized so that the runs of different auditors can
+// be stored, searched and compared in the same way
+type Issue struct {
+	Tool string `json:"tool"`
+	// pa11y code or lighthouse audit id
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Selector string `json:"selector,omitempty"`
+	Context  string `json:"context,omitempty"`
+	// only set for lighthouse audits
+	Score *float64 `json:"score,omitempty"`
+	// identifies the issue across runs, see IssueFingerprint
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// new, persisting or fixed relative to the previous run of the URL, see TrackIssueLifecycle
+	Status    string     `json:"status,omitempty"`
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	// only set for fixed issues
+	FixedAt *time.Time `json:"fixed_at,omitempty"`
+	// decision and owner from triage, see Triage.Annotate
+	Triage string `json:"triage,omitempty"`
+	Owner  string `json:"owner,omitempty"`
+}
+
+var (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityNotice  = "notice"
+)
+
+func NormalizePa11yIssues(report []Pa11yOutputErr) []Issue {
+	issues := []Issue{}
+
+	for i := range report {
+		severity := report[i].Type
+		if severity == "" {
+			severity = SeverityError
+		}
+
+		issues = append(issues, Issue{
+			Tool:     "pa11y",
+			Rule:     report[i].Code,
+			Message:  report[i].Message,
+			Severity: severity,
+			Selector: report[i].Selector,
+			Context:  report[i].Context,
+		})
+	}
+
+	return issues
+}
+
+// NormalizeLighthouseIssues returns the audits which didn't pass, audits scoring below 0.5 are treated as
+// errors and the rest as warnings (the same thresholds which lighthouse uses for coloring them)
+func NormalizeLighthouseIssues(audits []LighthouseAudit) []Issue {
+	issues := []Issue{}
+
+	for i := range audits {
+		if audits[i].Score == nil || *audits[i].Score >= 1 {
+			continue
+		}
+
+		severity := SeverityWarning
+		if *audits[i].Score < 0.5 {
+			severity = SeverityError
+		}
+
+		message := audits[i].Title
+		if message == "" {
+			message = audits[i].Id
+		}
+
+		issues = append(issues, Issue{
+			Tool:     "lighthouse",
+			Rule:     audits[i].Id,
+			Message:  message,
+			Severity: severity,
+			Score:    audits[i].Score,
+		})
+	}
+
+	return issues
+}
+
+// CountIssuesBySeverity returns the number of issues of each severity
+func CountIssuesBySeverity(issues []Issue) map[string]int {
+	counts := make(map[string]int)
+
+	for i := range issues {
+		counts[issues[i].Severity]++
+	}
+
+	return counts
+}
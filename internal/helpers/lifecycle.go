@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	IssueNew        = "new"
+	IssuePersisting = "persisting"
+	IssueFixed      = "fixed"
+)
+
+// IssueFingerprint identifies the issue across runs, so that it can be tracked from the run it was first
+// seen in until it is fixed. the selector tells apart the same rule failing on different elements, and
+// the message is used for the pa11y issues which don't have a selector. lighthouse audits are unique
+// per run, so their id is enough
+func IssueFingerprint(issue Issue) string {
+	if issue.Fingerprint != "" {
+		return issue.Fingerprint
+	}
+
+	parts := []string{issue.Tool, issue.Rule}
+
+	if issue.Selector != "" {
+		parts = append(parts, strings.Join(strings.Fields(issue.Selector), " "))
+	} else if issue.Tool != "lighthouse" {
+		parts = append(parts, strings.Join(strings.Fields(issue.Message), " "))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// matchIssues matches the issues of the head against the ones of the base by their fingerprint. it
+// returns the index of the matching base issue for each of the head issues (-1 if there is none) along
+// with the indexes of the base issues which weren't matched. the same issue might be reported more than
+// once, so each base issue is only matched once
+func matchIssues(base []Issue, head []Issue) ([]int, []int) {
+	remaining := make(map[string][]int)
+	for i := range base {
+		fingerprint := IssueFingerprint(base[i])
+		remaining[fingerprint] = append(remaining[fingerprint], i)
+	}
+
+	matches := make([]int, len(head))
+
+	for i := range head {
+		fingerprint := IssueFingerprint(head[i])
+
+		if len(remaining[fingerprint]) != 0 {
+			matches[i] = remaining[fingerprint][0]
+			remaining[fingerprint] = remaining[fingerprint][1:]
+		} else {
+			matches[i] = -1
+		}
+	}
+
+	matched := make([]bool, len(base))
+	for _, match := range matches {
+		if match != -1 {
+			matched[match] = true
+		}
+	}
+
+	var unmatched []int
+
+	for i := range base {
+		if !matched[i] {
+			unmatched = append(unmatched, i)
+		}
+	}
+
+	return matches, unmatched
+}
+
+// FindPreviousHistoryEntry returns the run of the same URL by the same auditor which came right before
+// the entry, or nil if there is none
+func FindPreviousHistoryEntry(entry HistoryEntry) (*HistoryEntry, error) {
+	entries, err := ListHistoryEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	// the index is sorted by the most recent run first
+	for _, indexEntry := range entries {
+		if indexEntry.Id == entry.Id || indexEntry.Tool != entry.Tool || !indexEntry.CreatedAt.Before(entry.CreatedAt) {
+			continue
+		}
+
+		if normalizeHistoryUrl(indexEntry.Url) != normalizeHistoryUrl(entry.Url) {
+			continue
+		}
+
+		previous, _, err := readHistoryEntry(indexEntry.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		return &previous, nil
+	}
+
+	return nil, nil
+}
+
+// TrackIssueLifecycle tags each of the issues of the entry as new or persisting relative to the previous
+// run, carrying over when persisting issues were first seen, and records the issues of the previous run
+// which are gone as fixed. without a previous run, all of the issues are new. the ignored issues are
+// matched as well, so an issue which is ignored since the previous run persists instead of being fixed,
+// and an ignored issue which is gone isn't recorded as fixed
+func TrackIssueLifecycle(entry *HistoryEntry, previous *HistoryEntry) {
+	createdAt := entry.CreatedAt
+
+	var previousIssues []Issue
+	if previous != nil {
+		previousIssues = append(append([]Issue{}, previous.Issues...), previous.Ignored...)
+		entry.Previous = previous.Id
+	}
+
+	issues := append(append([]Issue{}, entry.Issues...), entry.Ignored...)
+	matches, unmatched := matchIssues(previousIssues, issues)
+
+	for i := range issues {
+		issues[i].Fingerprint = IssueFingerprint(issues[i])
+
+		if matches[i] == -1 {
+			issues[i].Status = IssueNew
+			issues[i].FirstSeen = &createdAt
+			continue
+		}
+
+		issues[i].Status = IssuePersisting
+		issues[i].FirstSeen = firstSeen(previousIssues[matches[i]], previous)
+	}
+
+	n := len(entry.Issues)
+	copy(entry.Issues, issues[:n])
+	copy(entry.Ignored, issues[n:])
+
+	entry.Fixed = nil
+
+	for _, i := range unmatched {
+		if i >= len(previous.Issues) {
+			continue
+		}
+
+		issue := previousIssues[i]
+		issue.Fingerprint = IssueFingerprint(issue)
+		issue.Status = IssueFixed
+		issue.FirstSeen = firstSeen(issue, previous)
+		issue.FixedAt = &createdAt
+
+		entry.Fixed = append(entry.Fixed, issue)
+	}
+}
+
+// firstSeen falls back to the date of the run for the issues saved before their lifecycle was tracked
+func firstSeen(issue Issue, entry *HistoryEntry) *time.Time {
+	if issue.FirstSeen != nil {
+		return issue.FirstSeen
+	}
+
+	createdAt := entry.CreatedAt
+
+	return &createdAt
+}
+
+// IssueAge returns for how long the issue has been open, or how long it took to fix it for the fixed
+// issues. zero is returned if it isn't known when the issue was first seen
+func IssueAge(issue Issue, now time.Time) time.Duration {
+	if issue.FirstSeen == nil {
+		return 0
+	}
+
+	if issue.FixedAt != nil {
+		now = *issue.FixedAt
+	}
+
+	return max(now.Sub(*issue.FirstSeen), 0)
+}
+
+// FormatIssueAge formats the age in days, e.g. `<1 day`, `1 day` or `12 days`
+func FormatIssueAge(age time.Duration) string {
+	days := int(math.Floor(age.Hours() / 24))
+
+	switch days {
+	case 0:
+		return "<1 day"
+	case 1:
+		return "1 day"
+	default:
+		return fmt.Sprintf("%d days", days)
+	}
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackIssueLifecycleIgnored(t *testing.T) {
+	contrast := Issue{Tool: "lighthouse", Rule: "color-contrast", Severity: SeverityError}
+	console := Issue{Tool: "lighthouse", Rule: "errors-in-console", Severity: SeverityError}
+	title := Issue{Tool: "lighthouse", Rule: "document-title", Severity: SeverityError}
+
+	previousAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := HistoryEntry{
+		Id:        "previous",
+		CreatedAt: previousAt,
+		Issues:    []Issue{contrast, console},
+		Ignored:   []Issue{title},
+	}
+
+	entry := HistoryEntry{
+		Id:        "current",
+		CreatedAt: previousAt.Add(24 * time.Hour),
+		Issues:    []Issue{console},
+		// color-contrast is ignored since the previous run and document-title is gone
+		Ignored: []Issue{contrast},
+	}
+
+	TrackIssueLifecycle(&entry, &previous)
+
+	if entry.Issues[0].Status != IssuePersisting {
+		t.Errorf("expected %s to persist, got %s", console.Rule, entry.Issues[0].Status)
+	}
+
+	if entry.Ignored[0].Status != IssuePersisting || !entry.Ignored[0].FirstSeen.Equal(previousAt) {
+		t.Errorf("expected the ignored %s to persist since %s, got %s since %v", contrast.Rule, previousAt, entry.Ignored[0].Status, entry.Ignored[0].FirstSeen)
+	}
+
+	if len(entry.Fixed) != 0 {
+		t.Errorf("expected no fixed issues, got %v", entry.Fixed)
+	}
+}
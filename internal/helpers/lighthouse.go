@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/utils"
+)
+
+// LighthouseCategories are the categories which lighthouse scores, in the order in which they are
+// reported. `pwa` was dropped by lighthouse 12, so it is only scored by the older versions
+var LighthouseCategories = []string{"performance", "accessibility", "best-practices", "seo", "pwa"}
+
+type LighthouseCategory struct {
+	Id        string               `json:"id"`
+	Title     string               `json:"title"`
+	Score     *float64             `json:"score"`
+	AuditRefs []LighthouseAuditRef `json:"auditRefs"`
+}
+
+type LighthouseAuditRef struct {
+	Id     string  `json:"id"`
+	Weight float64 `json:"weight"`
+	Group  string  `json:"group,omitempty"`
+}
+
+// LighthouseCategoryScore is the score of a category along with how each of its audits contributed to it
+type LighthouseCategoryScore struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+	// out of 100, as reported by lighthouse. nil if lighthouse couldn't score the category
+	Score  *float64                  `json:"score"`
+	Audits []LighthouseCategoryAudit `json:"audits"`
+}
+
+type LighthouseCategoryAudit struct {
+	Id     string   `json:"id"`
+	Title  string   `json:"title"`
+	Score  *float64 `json:"score"`
+	Weight float64  `json:"weight"`
+	// points which the audit took off the score of the category, out of 100
+	Impact float64 `json:"impact"`
+	// ignored via the project config or skipped during triage. lighthouse still counts it in the score
+	// of the category
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// audits which lighthouse leaves out of the scores of the categories, whatever their weight is
+var unscoredLighthouseAuditModes = []string{"manual", "notApplicable", "informative"}
+
+// LighthouseMetricKey returns the key of the metric which the score of the category is saved as, e.g.
+// `best_practices` for `best-practices`
+func LighthouseMetricKey(category string) string {
+	return strings.ReplaceAll(category, "-", "_")
+}
+
+// ScoreLighthouseCategories returns the score which lighthouse gave to each of the categories of the
+// report, along with how much each of the audits took off it. lighthouse scores a category as the
+// weighted mean of the scores of its audits, where the manual, not applicable and informative audits
+// don't count and the audits which errored count as 0. skips flags the audits which were ignored or
+// skipped during triage
+func ScoreLighthouseCategories(report LighthouseReport, skips func(id string) bool) []LighthouseCategoryScore {
+	var ids []string
+
+	for _, id := range LighthouseCategories {
+		if _, ok := report.Categories[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+
+	// categories of plugins come after the built-in ones
+	var others []string
+	for id := range report.Categories {
+		if !utils.OneOfThem(id, LighthouseCategories) {
+			others = append(others, id)
+		}
+	}
+
+	sort.Strings(others)
+	ids = append(ids, others...)
+
+	var scores []LighthouseCategoryScore
+
+	for _, id := range ids {
+		category := report.Categories[id]
+		score := LighthouseCategoryScore{Id: id, Title: category.Title, Audits: []LighthouseCategoryAudit{}}
+
+		if category.Score != nil {
+			value := *category.Score * 100
+			score.Score = &value
+		}
+
+		total := 0.0
+
+		for _, ref := range category.AuditRefs {
+			audit, ok := report.Audits[ref.Id]
+			if !ok || ref.Weight == 0 || utils.OneOfThem(audit.ScoreDisplayMode, unscoredLighthouseAuditModes) {
+				continue
+			}
+
+			total += ref.Weight
+			score.Audits = append(score.Audits, LighthouseCategoryAudit{Id: ref.Id, Title: audit.Title, Score: audit.Score, Weight: ref.Weight, Skipped: skips(ref.Id)})
+		}
+
+		for i := range score.Audits {
+			auditScore := 0.0
+			if score.Audits[i].Score != nil {
+				auditScore = *score.Audits[i].Score
+			}
+
+			score.Audits[i].Impact = score.Audits[i].Weight * (1 - auditScore) / total * 100
+		}
+
+		// the audits which took the most off the score come first
+		sort.SliceStable(score.Audits, func(i, j int) bool {
+			return score.Audits[i].Impact > score.Audits[j].Impact
+		})
+
+		scores = append(scores, score)
+	}
+
+	return scores
+}
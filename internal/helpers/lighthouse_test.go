@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestScoreLighthouseCategories(t *testing.T) {
+	// report of lighthouse 12 trimmed down to a few of the audits of each category
+	data, err := os.ReadFile("testdata/lighthouse.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report LighthouseReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	categories := ScoreLighthouseCategories(report, func(id string) bool {
+		return id == "color-contrast"
+	})
+
+	expected := []struct {
+		id    string
+		score float64
+	}{
+		{"performance", 72},
+		{"accessibility", 51},
+		{"best-practices", 92},
+		{"seo", 75},
+	}
+
+	if len(categories) != len(expected) {
+		t.Fatalf("expected %d categories, got %d", len(expected), len(categories))
+	}
+
+	for i, category := range categories {
+		if category.Id != expected[i].id {
+			t.Errorf("expected category %d to be %s, got %s", i, expected[i].id, category.Id)
+			continue
+		}
+
+		// skipped audits don't change the scores, which are the ones of lighthouse
+		if category.Score == nil || math.Abs(*category.Score-expected[i].score) > 1e-9 {
+			t.Errorf("expected %s to score %.0f, got %v", category.Id, expected[i].score, category.Score)
+			continue
+		}
+
+		// lighthouse rounds the scores to 2 decimals, so the impacts add up to roughly what is missing
+		impact := 0.0
+		for _, audit := range category.Audits {
+			impact += audit.Impact
+		}
+
+		if math.Abs(impact-(100-*category.Score)) > 0.5 {
+			t.Errorf("expected the audits of %s to take %.0f off its score, got %.2f", category.Id, 100-*category.Score, impact)
+		}
+	}
+
+	audits := map[string]LighthouseCategoryAudit{}
+	for _, category := range categories {
+		for _, audit := range category.Audits {
+			audits[category.Id+"/"+audit.Id] = audit
+		}
+	}
+
+	for _, id := range []string{"performance/render-blocking-resources", "accessibility/aria-allowed-attr", "accessibility/focus-traps", "best-practices/csp-xss", "seo/canonical"} {
+		if _, ok := audits[id]; ok {
+			t.Errorf("expected %s to be left out, as lighthouse doesn't count it", id)
+		}
+	}
+
+	if audit := audits["accessibility/color-contrast"]; !audit.Skipped || audit.Impact == 0 {
+		t.Errorf("expected color-contrast to be skipped and still take off the score, got %+v", audit)
+	}
+
+	// audits which errored count as 0
+	if audit := audits["seo/robots-txt"]; audit.Score != nil || audit.Impact == 0 {
+		t.Errorf("expected robots-txt to take off the score, got %+v", audit)
+	}
+}
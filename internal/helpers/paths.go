@@ -0,0 +1,180 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// set via the `--config` flag
+var configFilePathOverride string
+
+func SetConfigFilePath(path string) {
+	configFilePathOverride = path
+}
+
+// xdgDir returns `$<envVar>/insightly`, falling back to `~/<fallback>/insightly` if the variable isn't set.
+// if `INSIGHTLY_HOME` is set, everything is stored under `$INSIGHTLY_HOME/<name>` instead
+func xdgDir(envVar string, fallback string, name string) string {
+	if home := os.Getenv("INSIGHTLY_HOME"); home != "" {
+		return filepath.Join(home, name)
+	}
+
+	if dir := os.Getenv(envVar); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "insightly")
+	}
+
+	homedir, _ := os.UserHomeDir()
+	return filepath.Join(homedir, fallback, "insightly")
+}
+
+func GetConfigDirPath() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config", "config")
+}
+
+func GetCacheDirPath() string {
+	return xdgDir("XDG_CACHE_HOME", ".cache", "cache")
+}
+
+func GetDataDirPath() string {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"), "data")
+}
+
+func ensureDir(path string) error {
+	return os.MkdirAll(path, 0700)
+}
+
+// MigrateLegacyFiles moves the files which were stored in the home directory by older versions to their
+// XDG locations and returns what was done. files which already exist at the new location are left
+// untouched, and the legacy ones are kept as `<path>.migrated` so that they are only reported once
+func MigrateLegacyFiles() ([]string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+
+	var migrated []string
+
+	legacyConfigFilePath := filepath.Join(homedir, ".something.config.json")
+	configFilePath := filepath.Join(GetConfigDirPath(), "config.json")
+
+	moved, err := moveIfMissing(legacyConfigFilePath, configFilePath)
+	if err != nil {
+		return migrated, err
+	}
+
+	if moved {
+		// the config file holds the API keys in plaintext, whereas the legacy one was world-readable
+		if err := os.Chmod(configFilePath, 0600); err != nil {
+			return migrated, err
+		}
+
+		migrated = append(migrated, fmt.Sprintf("Moved %s -> %s", legacyConfigFilePath, configFilePath))
+	} else if _, err := os.Stat(legacyConfigFilePath); err == nil {
+		keptPath, err := keepLegacyPath(legacyConfigFilePath)
+		if err != nil {
+			return migrated, err
+		}
+
+		if err := os.Chmod(keptPath, 0600); err != nil {
+			return migrated, err
+		}
+
+		migrated = append(migrated, fmt.Sprintf("Kept %s as %s, as %s already exists", legacyConfigFilePath, keptPath, configFilePath))
+	}
+
+	legacyHistoryDirPath := filepath.Join(homedir, "something_history")
+
+	entries, err := os.ReadDir(legacyHistoryDirPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return migrated, err
+	}
+
+	movedEntries := 0
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+
+		if err := migrateLegacySummary(filepath.Join(legacyHistoryDirPath, entry.Name())); err != nil {
+			return migrated, fmt.Errorf("failed to migrate history entry %s: %w", entry.Name(), err)
+		}
+
+		movedEntries++
+	}
+
+	if movedEntries != 0 {
+		if err := RebuildHistoryStore(); err != nil {
+			return migrated, err
+		}
+
+		migrated = append(migrated, fmt.Sprintf("Moved %d history entries %s -> %s", movedEntries, legacyHistoryDirPath, GetHistoryDirPath()))
+	}
+
+	// only removed if nothing else was kept in it
+	_ = os.Remove(legacyHistoryDirPath)
+
+	// leftover of a lighthouse run which was interrupted
+	_ = os.Remove(filepath.Join(homedir, ".something.lighthouse.tmp.json"))
+
+	return migrated, nil
+}
+
+// keepLegacyPath renames the legacy file (or directory) which couldn't be moved, so that it isn't picked up
+// again by the next run
+func keepLegacyPath(oldPath string) (string, error) {
+	keptPath := oldPath + ".migrated"
+
+	return keptPath, os.Rename(oldPath, keptPath)
+}
+
+func moveIfMissing(oldPath string, newPath string) (bool, error) {
+	if _, err := os.Stat(oldPath); err != nil {
+		return false, nil
+	}
+
+	if _, err := os.Stat(newPath); err == nil {
+		return false, nil
+	}
+
+	if err := ensureDir(filepath.Dir(newPath)); err != nil {
+		return false, err
+	}
+
+	if err := os.Rename(oldPath, newPath); err == nil {
+		return true, nil
+	}
+
+	// rename fails if the paths are on different filesystems
+	if err := copyFile(oldPath, newPath); err != nil {
+		return false, err
+	}
+
+	return true, os.Remove(oldPath)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// DefaultProfile is the name of the profile which is made up of the top-level fields of the config file
+const DefaultProfile = "default"
+
+// set via the `--profile` flag
+var profileOverride string
+
+var profileNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// AuditDefaults are used by `gen-ux` when neither the flags nor the project config set them
+type AuditDefaults struct {
+	Auditors   []string `json:"auditors,omitempty" mapstructure:"auditors"`
+	Standard   string   `json:"standard,omitempty" mapstructure:"standard"`
+	UseAi      bool     `json:"use_ai,omitempty" mapstructure:"use_ai"`
+	SaveReport bool     `json:"save_report,omitempty" mapstructure:"save_report"`
+	Persona    string   `json:"persona,omitempty" mapstructure:"persona"`
+}
+
+// Profile holds its own set of LLMs, default LLM, fallback chain and audit defaults
+type Profile struct {
+	Default  Llm           `json:"default" mapstructure:"default"`
+	Llms     []LlmConfig   `json:"llms" mapstructure:"llms"`
+	Fallback []Llm         `json:"fallback,omitempty" mapstructure:"fallback"`
+	Audit    AuditDefaults `json:"audit,omitempty" mapstructure:"audit"`
+}
+
+func SetProfile(name string) {
+	profileOverride = name
+}
+
+// GetProfileName returns the profile which is used for this run, in the following order:
+//
+//  1. `--profile` flag
+//  2. `INSIGHTLY_PROFILE` environment variable
+//  3. profile selected via `config profile use`
+func GetProfileName(config ConfigFile) string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+
+	if profile := os.Getenv("INSIGHTLY_PROFILE"); profile != "" {
+		return profile
+	}
+
+	if config.Profile != "" {
+		return config.Profile
+	}
+
+	return DefaultProfile
+}
+
+// ListProfiles returns the names of all of the profiles, starting with the default profile
+func ListProfiles(config ConfigFile) []string {
+	var names []string
+
+	for name := range config.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return append([]string{DefaultProfile}, names...)
+}
+
+func ValidateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return errors.New("profile names can only contain lowercase letters, digits, `-` and `_`")
+	}
+
+	return nil
+}
+
+func currentProfile(config ConfigFile) Profile {
+	return Profile{
+		Default:  config.Default,
+		Llms:     config.Llms,
+		Fallback: config.Fallback,
+		Audit:    config.Audit,
+	}
+}
+
+func setCurrentProfile(config *ConfigFile, profile Profile) {
+	config.Default = profile.Default
+	config.Llms = profile.Llms
+	config.Fallback = profile.Fallback
+	config.Audit = profile.Audit
+}
+
+// applyProfile swaps the selected profile into the top-level fields, so that the rest of the commands
+// don't have to know about profiles. the top-level fields are parked under the default profile until the
+// config is written back, see storeProfile
+func applyProfile(config ConfigFile) (ConfigFile, error) {
+	name := GetProfileName(config)
+	if name == DefaultProfile {
+		return config, nil
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return ConfigFile{}, fmt.Errorf("profile %s doesn't exist. Run `config profile list` to list the profiles or `config profile create %s` to create it", name, name)
+	}
+
+	profiles := make(map[string]Profile, len(config.Profiles)+1)
+	for n, p := range config.Profiles {
+		profiles[n] = p
+	}
+
+	profiles[DefaultProfile] = currentProfile(config)
+
+	config.Profiles = profiles
+	config.loadedProfile = name
+	setCurrentProfile(&config, profile)
+
+	return config, nil
+}
+
+// storeProfile reverses applyProfile before the config is written to the config file
+func storeProfile(config ConfigFile) ConfigFile {
+	if config.loadedProfile == "" {
+		return config
+	}
+
+	profiles := make(map[string]Profile, len(config.Profiles))
+	for n, p := range config.Profiles {
+		profiles[n] = p
+	}
+
+	profiles[config.loadedProfile] = currentProfile(config)
+	setCurrentProfile(&config, profiles[DefaultProfile])
+	delete(profiles, DefaultProfile)
+
+	config.Profiles = profiles
+	config.loadedProfile = ""
+
+	return config
+}
+
+// apiKeyAccount returns the name under which the API key of the LLM is saved in the key store. keys of
+// non-default profiles are namespaced, as each profile can have its own key for the same LLM
+func apiKeyAccount(config ConfigFile, llm Llm) string {
+	if config.loadedProfile == "" {
+		return string(llm)
+	}
+
+	return profileApiKeyAccount(config.loadedProfile, llm)
+}
+
+func profileApiKeyAccount(profile string, llm Llm) string {
+	if profile == DefaultProfile {
+		return string(llm)
+	}
+
+	return fmt.Sprintf("%s/%s", profile, llm)
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+var ProjectConfigFileNames = []string{".insightly.yaml", ".insightly.yml"}
+
+var ValidAuditors = []string{"lighthouse", "pa11y"}
+
+var ValidOutputFormats = []string{"json", "html", "markdown", "csv", "sarif"}
+
+type Pa11yProjectConfig struct {
+	Standard string `yaml:"standard"`
+}
+
+type ThresholdsProjectConfig struct {
+	// minimum lighthouse score of each category, out of 100, e.g. `performance: 90`
+	Scores map[string]float64 `yaml:"scores"`
+	// maximum number of pa11y issues, after applying the ignore rules
+	MaxIssues *int `yaml:"max_issues"`
+	// how much worse a metric can get from one run to the next before `trend` flags it as a regression,
+	// in percent
+	RegressionTolerance *float64 `yaml:"regression_tolerance"`
+}
+
+type PromptProjectConfig struct {
+	Persona string `yaml:"persona"`
+}
+
+// ProjectConfig is read from `.insightly.yaml`, which is checked into the repository so that everyone
+// audits the project in the same way. its values are merged over the global config file and are
+// overridden by the flags which are passed explicitly
+type ProjectConfig struct {
+	Urls       []string                `yaml:"urls"`
+	Auditors   []string                `yaml:"auditors"`
+	Pa11y      Pa11yProjectConfig      `yaml:"pa11y"`
+	Thresholds ThresholdsProjectConfig `yaml:"thresholds"`
+	Ignore     []string                `yaml:"ignore"`
+	Prompt     PromptProjectConfig     `yaml:"prompt"`
+	Outputs    []string                `yaml:"outputs"`
+	UseAi      bool                    `yaml:"use_ai"`
+	Llm        Llm                     `yaml:"llm"`
+}
+
+// FindProjectConfig walks up from the current working directory and returns the first project config
+// file which it finds along with its path. an empty path is returned if there is no project config file
+func FindProjectConfig() (ProjectConfig, string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ProjectConfig{}, "", err
+	}
+
+	for {
+		for _, name := range ProjectConfigFileNames {
+			path := filepath.Join(dir, name)
+
+			bytes, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return ProjectConfig{}, "", err
+			}
+
+			var projectConfig ProjectConfig
+
+			if err := yaml.Unmarshal(bytes, &projectConfig); err != nil {
+				return ProjectConfig{}, "", fmt.Errorf("invalid project config %s: %w", path, err)
+			}
+
+			if err := projectConfig.Validate(); err != nil {
+				return ProjectConfig{}, "", fmt.Errorf("invalid project config %s: %w", path, err)
+			}
+
+			return projectConfig, path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ProjectConfig{}, "", nil
+		}
+
+		dir = parent
+	}
+}
+
+func (p ProjectConfig) Validate() error {
+	for _, auditor := range p.Auditors {
+		if !utils.OneOfThem(auditor, ValidAuditors) {
+			return fmt.Errorf("invalid auditor %s, valid auditors are %s", auditor, strings.Join(ValidAuditors, ", "))
+		}
+	}
+
+	// outputs are either formats, which are saved as `report.<extension>`, or paths as passed to `--output`
+	for _, output := range p.Outputs {
+		if utils.OneOfThem(output, ValidOutputFormats) {
+			continue
+		}
+
+		if _, _, err := ParseOutput(output); err != nil {
+			return fmt.Errorf("invalid output %s, outputs are either one of %s or a path: %w", output, strings.Join(ValidOutputFormats, ", "), err)
+		}
+	}
+
+	for category, score := range p.Thresholds.Scores {
+		if !utils.OneOfThem(category, LighthouseCategories) {
+			return fmt.Errorf("invalid category %s in the score thresholds, valid categories are %s", category, strings.Join(LighthouseCategories, ", "))
+		}
+
+		if score < 0 || score > 100 {
+			return fmt.Errorf("invalid score threshold %.2f of %s, it has to be between 0 and 100", score, category)
+		}
+	}
+
+	if p.Thresholds.RegressionTolerance != nil && *p.Thresholds.RegressionTolerance < 0 {
+		return fmt.Errorf("invalid regression tolerance %.2f, it can't be negative", *p.Thresholds.RegressionTolerance)
+	}
+
+	if p.Llm != "" && !utils.OneOfThem(string(p.Llm), ValidLlms) {
+		return fmt.Errorf("invalid llm %s, valid LLMs are %s", p.Llm, strings.Join(ValidLlms, ", "))
+	}
+
+	return nil
+}
+
+// IsIgnored reports whether the pa11y code or lighthouse audit id matches any of the ignore rules. rules
+// ending with `*` match by prefix
+func IsIgnored(id string, rules []string) bool {
+	for _, rule := range rules {
+		if strings.HasSuffix(rule, "*") && strings.HasPrefix(id, strings.TrimSuffix(rule, "*")) {
+			return true
+		}
+
+		if id == rule {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,130 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuildUxReportPrompt renders the prompt for summarizing the report. persona is prepended to the prompt,
+// and can be used for tailoring the summary to the project, e.g. its tech stack or audience
+func BuildUxReportPrompt(llm Llm, usePa11y bool, report string, persona string) string {
+	var prompt string
+
+	if persona != "" {
+		prompt += strings.TrimSpace(persona) + "\n\n"
+	}
+
+	if usePa11y {
+		if llm == Gemini {
+			prompt += `Please restructure the pa11y report into a structured format, highlighting key issues and their corresponding solutions. Employ technical language and leverage specific data from the report. In the "Additional Considerations" section, categorize recommendations based on SEO, performance, and accessibility, focusing on major and critical points. The Pa11y repot is JSON format and it contains "code", "message" and "context". Just show the structured format irrespective of whether there is a single issue. Respond in markdown. No need to re-mention the issues. Don't have any additional footer text. Don't generate a table of issues. `
+			prompt += "\n"
+			prompt += fmt.Sprintf("```\n%s```\n", report)
+		} else {
+			prompt += "Here is an accessibility report generated by pa11y. It is in JSON format and it contains the `code`, `message` and `context`.\n"
+			prompt += fmt.Sprintf("```\n%s```\n", report)
+			prompt += "Give suggestions regarding how to improve the accessibility and how to fix the errors mentioned by pa11y. Just only the solutions for those issues and also few other suggestions regarding how to improve the UX and accessiblity. Don't render a table of the JSON input. Just mention solution of every issue in a list style manner.\n"
+			prompt += "END_OF_PROMPT"
+		}
+	} else {
+		if llm == Gemini {
+			prompt += `Please restructure the Lighthouse report into a structured format, highlighting key issues and their corresponding solutions. Employ technical language and leverage specific data from the report. In the "Additional Considerations" section, categorize recommendations based on SEO, performance, and accessibility, focusing on major and critical points. Just show the structured format irrespective of whether there is a single issue. Respond in markdown. No need to re-mention the issues. Don't generate a table of issues. Don't have any additional footer text`
+			prompt += "\n"
+			prompt += fmt.Sprintf("```\n%s```\n", report)
+		} else {
+			prompt += "Here is an accessibility report generated by pa11y. It is in JSON format and it contains the `code`, `message` and `context`.\n"
+			prompt += fmt.Sprintf("```\n%s```\n", report)
+			prompt += "Give suggestions regarding how to improve the accessibility and how to fix the errors mentioned by pa11y. Just only the solutions for those issues and also few other suggestions regarding how to improve the UX and accessiblity. Don't render a table of the JSON input. Just mention solution of every issue in a list style manner.\n"
+			prompt += "END_OF_PROMPT"
+		}
+	}
+
+	return prompt
+}
+
+// BuildChatPrompt renders the whole conversation as a single prompt, as none of the supported LLMs are
+// queried via a chat API
+func BuildChatPrompt(llm Llm, entry HistoryEntry, summary string, turns []ChatTurn) string {
+	var prompt strings.Builder
+
+	prompt.WriteString("You are an expert in web accessibility and UX. You are helping a developer fix the issues found in an accessibility report. Answer their questions using the report and its summary as context. Be specific, include code snippets where useful and respond in markdown.\n\n")
+
+	if entry.Url != "" {
+		prompt.WriteString(fmt.Sprintf("The report was generated for %s using %s.\n\n", entry.Url, entry.Tool))
+	}
+
+	if entry.Report != "" {
+		prompt.WriteString(fmt.Sprintf("Here is the report:\n```\n%s```\n\n", entry.Report))
+	}
+
+	// runs without `--use-ai` don't have a summary
+	if summary != "" {
+		prompt.WriteString(fmt.Sprintf("Here is the summary of the report:\n%s\n\n", summary))
+	}
+	prompt.WriteString("Here is the conversation so far:\n\n")
+
+	for i := range turns {
+		prompt.WriteString(fmt.Sprintf("%s: %s\n\n", turns[i].Role, turns[i].Content))
+	}
+
+	prompt.WriteString("assistant:")
+
+	if llm != Gemini {
+		prompt.WriteString("\nEND_OF_PROMPT")
+	}
+
+	return prompt.String()
+}
+
+// BuildConsensusPrompt asks the LLM to merge the answers of multiple LLMs into a single list of fixes,
+// ranked by the number of LLMs which suggested each of them
+func BuildConsensusPrompt(llm Llm, answers []LlmAnswer) string {
+	var prompt strings.Builder
+	var count int
+
+	prompt.WriteString("Multiple AI models were asked how to fix the issues in the same accessibility report. Here are their answers:\n\n")
+
+	for i := range answers {
+		if answers[i].Err != nil {
+			continue
+		}
+
+		count++
+		prompt.WriteString(fmt.Sprintf("--- answer from %s ---\n%s\n\n", answers[i].Llm, answers[i].Output))
+	}
+
+	prompt.WriteString(fmt.Sprintf("Merge these %d answers into a single consensus list of fixes. Group fixes which mean the same thing even if they are worded differently. Rank the fixes by how many models suggested them, most agreed upon first, and for each fix mention the number of models which agreed along with their names, e.g. \"(3/%d - gemini, qwen, mistral)\". Respond in markdown. Don't have any additional header or footer text.\n", count, count))
+
+	if llm != Gemini {
+		prompt.WriteString("END_OF_PROMPT")
+	}
+
+	return prompt.String()
+}
+
+// BuildIssueFixPrompt asks the LLM how to fix a single issue of the run, which is used while triaging
+func BuildIssueFixPrompt(llm Llm, entry HistoryEntry, issue Issue, persona string) string {
+	var prompt strings.Builder
+
+	if persona != "" {
+		prompt.WriteString(strings.TrimSpace(persona) + "\n\n")
+	}
+
+	prompt.WriteString(fmt.Sprintf("You are an expert in web accessibility and UX. %s reported the following issue on %s:\n\n", issue.Tool, entry.Url))
+	prompt.WriteString(fmt.Sprintf("Rule: %s\nSeverity: %s\nMessage: %s\n", issue.Rule, issue.Severity, issue.Message))
+
+	if issue.Selector != "" {
+		prompt.WriteString(fmt.Sprintf("Selector: %s\n", issue.Selector))
+	}
+
+	if issue.Context != "" {
+		prompt.WriteString(fmt.Sprintf("Offending markup:\n```\n%s\n```\n", issue.Context))
+	}
+
+	prompt.WriteString("\nExplain briefly why this is a problem and how to fix it, including the corrected markup or code where useful. Respond in markdown. Don't have any additional header or footer text.\n")
+
+	if llm != Gemini {
+		prompt.WriteString("END_OF_PROMPT")
+	}
+
+	return prompt.String()
+}
@@ -0,0 +1,190 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type RedactionPattern struct {
+	Name    string `json:"name" mapstructure:"name"`
+	Pattern string `json:"pattern" mapstructure:"pattern"`
+}
+
+type RedactionConfig struct {
+	Disabled bool               `json:"disabled,omitempty" mapstructure:"disabled"`
+	Patterns []RedactionPattern `json:"patterns,omitempty" mapstructure:"patterns"`
+}
+
+type Redaction struct {
+	Detector string
+	Value    string
+}
+
+type redactionDetector struct {
+	name     string
+	regex    *regexp.Regexp
+	validate func(match string) bool
+}
+
+type Redactor struct {
+	detectors []redactionDetector
+}
+
+// built-in detectors are applied in order, so the more specific ones (jwt, known key prefixes)
+// run before the generic ones and don't get partially replaced by them
+var builtinRedactionDetectors = []redactionDetector{
+	{
+		name:  "jwt",
+		regex: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+	},
+	{
+		name:  "api_key",
+		regex: regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|AIza[0-9A-Za-z_-]{35}|hf_[A-Za-z0-9]{30,}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abposr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`),
+	},
+	{
+		name:  "url_secret",
+		regex: regexp.MustCompile(`(?i)(?:api[_-]?key|access[_-]?token|auth[_-]?token|token|secret|password|passwd|session[_-]?id|sig|signature)=[^&\s"'<>\\]{6,}`),
+	},
+	{
+		name:  "email",
+		regex: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		name:     "credit_card",
+		regex:    regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		validate: isLuhnValid,
+	},
+}
+
+func NewRedactor(patterns []RedactionPattern) (*Redactor, error) {
+	detectors := make([]redactionDetector, len(builtinRedactionDetectors))
+	copy(detectors, builtinRedactionDetectors)
+
+	for i := range patterns {
+		regex, err := regexp.Compile(patterns[i].Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", patterns[i].Name, err)
+		}
+
+		name := patterns[i].Name
+		if name == "" {
+			name = "custom"
+		}
+
+		detectors = append(detectors, redactionDetector{
+			name:  name,
+			regex: regex,
+		})
+	}
+
+	return &Redactor{detectors: detectors}, nil
+}
+
+// Redact replaces every sensitive value found in input with a `[REDACTED_<DETECTOR>]` placeholder and
+// returns the redacted string along with the list of values which were replaced
+func (r *Redactor) Redact(input string) (string, []Redaction) {
+	var redactions []Redaction
+
+	for _, detector := range r.detectors {
+		placeholder := fmt.Sprintf("[REDACTED_%s]", strings.ToUpper(detector.name))
+
+		input = detector.regex.ReplaceAllStringFunc(input, func(match string) string {
+			if detector.validate != nil && !detector.validate(match) {
+				return match
+			}
+
+			// `url_secret` matches the whole `key=value` pair, only the value has to go
+			if detector.name == "url_secret" {
+				key, value, _ := strings.Cut(match, "=")
+				redactions = append(redactions, Redaction{Detector: detector.name, Value: value})
+				return key + "=" + placeholder
+			}
+
+			redactions = append(redactions, Redaction{Detector: detector.name, Value: match})
+			return placeholder
+		})
+	}
+
+	return input, redactions
+}
+
+// RedactIssues redacts the messages, selectors and contexts of the issues in place. the fingerprints are
+// computed beforehand from the original values, so that the issues still match the ones of previous runs
+// and the triage decisions
+func (r *Redactor) RedactIssues(issues []Issue) []Redaction {
+	var redactions []Redaction
+
+	for i := range issues {
+		issues[i].Fingerprint = IssueFingerprint(issues[i])
+
+		for _, field := range []*string{&issues[i].Message, &issues[i].Selector, &issues[i].Context} {
+			var found []Redaction
+
+			*field, found = r.Redact(*field)
+			redactions = append(redactions, found...)
+		}
+	}
+
+	return redactions
+}
+
+// SummarizeRedactions groups the redactions by detector and returns the count of values per detector,
+// sorted by detector name
+func SummarizeRedactions(redactions []Redaction) []string {
+	counts := make(map[string]int)
+
+	for i := range redactions {
+		counts[redactions[i].Detector]++
+	}
+
+	var lines []string
+
+	for detector, count := range counts {
+		lines = append(lines, fmt.Sprintf("%s - %d", detector, count))
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+// DescribeRedaction describes the redacted value by its detector and length only, e.g. `[email] 20 chars`,
+// as even a partially masked value might give away short secrets
+func DescribeRedaction(redaction Redaction) string {
+	return fmt.Sprintf("[%s] %d chars", redaction.Detector, len([]rune(redaction.Value)))
+}
+
+func isLuhnValid(number string) bool {
+	var digits []int
+
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, int(r-'0'))
+		}
+	}
+
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := digits[i]
+
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,319 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/utils"
+)
+
+// ReportRun is a run along with its AI summary, which is empty if the run wasn't summarized
+type ReportRun struct {
+	Entry   HistoryEntry
+	Summary string
+}
+
+// file extensions of the formats which the reports can be rendered in, see RenderReport
+var ReportFormatExtensions = map[string]string{
+	"json":     "json",
+	"html":     "html",
+	"markdown": "md",
+	"csv":      "csv",
+	"sarif":    "sarif",
+}
+
+// ParseOutput splits the output of `--output` into its format and path. the format is inferred from the
+// extension of the path, unless the path is prefixed with it, e.g. `markdown:-`. `-` is stdout, which is
+// json unless it is prefixed with a format
+func ParseOutput(output string) (string, string, error) {
+	if format, path, ok := strings.Cut(output, ":"); ok && utils.OneOfThem(format, ValidOutputFormats) {
+		return format, path, nil
+	}
+
+	if output == "-" {
+		return "json", output, nil
+	}
+
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(output), "."))
+
+	switch extension {
+	case "htm":
+		return "html", output, nil
+	case "markdown":
+		return "markdown", output, nil
+	}
+
+	for _, format := range ValidOutputFormats {
+		if ReportFormatExtensions[format] == extension {
+			return format, output, nil
+		}
+	}
+
+	var extensions []string
+	for _, format := range ValidOutputFormats {
+		extensions = append(extensions, "."+ReportFormatExtensions[format])
+	}
+
+	return "", "", fmt.Errorf("can't infer the format of %s from its extension, valid extensions are %s. prefix it with the format otherwise, e.g. `json:%s`", output, strings.Join(extensions, ", "), output)
+}
+
+// ExpandOutputPath replaces the placeholders of the path with the values of the run, i.e. `{host}`,
+// `{tool}`, `{id}`, `{date}` and `{time}`
+func ExpandOutputPath(path string, entry HistoryEntry) string {
+	host := entry.Url
+	if parsedUrl, err := url.Parse(entry.Url); err == nil && parsedUrl.Host != "" {
+		host = parsedUrl.Host
+	}
+
+	return strings.NewReplacer(
+		"{host}", strings.ReplaceAll(host, ":", "_"),
+		"{tool}", entry.Tool,
+		"{id}", entry.Id,
+		"{date}", entry.CreatedAt.Local().Format("2006-01-02"),
+		"{time}", entry.CreatedAt.Local().Format("15-04-05"),
+	).Replace(path)
+}
+
+// RenderReport renders the runs in the given format. json is the raw report of the run, or an array of the
+// raw reports if there are multiple runs
+func RenderReport(format string, runs []ReportRun) ([]byte, error) {
+	switch format {
+	case "json":
+		if len(runs) == 1 {
+			return []byte(runs[0].Entry.Report), nil
+		}
+
+		var reports []json.RawMessage
+		for _, run := range runs {
+			reports = append(reports, json.RawMessage(run.Entry.Report))
+		}
+
+		return json.MarshalIndent(reports, "", " ")
+	case "html":
+		return RenderHtmlReport(runs)
+	case "markdown":
+		return []byte(RenderMarkdownReport(runs)), nil
+	case "csv":
+		return RenderCsvReport(runs)
+	case "sarif":
+		return RenderSarifReport(runs)
+	}
+
+	return nil, fmt.Errorf("invalid format %s, valid formats are %s", format, strings.Join(ValidOutputFormats, ", "))
+}
+
+type reportPage struct {
+	Url    string
+	Issues []Issue
+}
+
+// reportCriterion is a WCAG criterion along with the issues of each of the pages which fail it
+type reportCriterion struct {
+	Title  string
+	Issues int
+	Pages  []reportPage
+}
+
+// groupIssuesByCriterion groups the issues of the runs by the WCAG criterion which they fail and then by
+// page. issues which don't map to a criterion are grouped under "Other", which comes last
+func groupIssuesByCriterion(runs []ReportRun) []reportCriterion {
+	criteria := make(map[string]*reportCriterion)
+
+	for _, run := range runs {
+		for _, issue := range run.Entry.Issues {
+			criterion := WcagCriterion(issue)
+
+			group, ok := criteria[criterion]
+			if !ok {
+				group = &reportCriterion{Title: "Other"}
+
+				if criterion != "" {
+					group.Title = fmt.Sprintf("%s %s", criterion, WcagCriteria[criterion])
+				}
+
+				criteria[criterion] = group
+			}
+
+			group.Issues++
+
+			// the same page might have been audited by both pa11y and lighthouse, so the pages are merged
+			i := 0
+			for i < len(group.Pages) && group.Pages[i].Url != run.Entry.Url {
+				i++
+			}
+
+			if i == len(group.Pages) {
+				group.Pages = append(group.Pages, reportPage{Url: run.Entry.Url})
+			}
+
+			group.Pages[i].Issues = append(group.Pages[i].Issues, issue)
+		}
+	}
+
+	var keys []string
+	for criterion := range criteria {
+		keys = append(keys, criterion)
+	}
+
+	SortWcagCriteria(keys)
+
+	var groups []reportCriterion
+
+	for _, criterion := range keys {
+		group := criteria[criterion]
+
+		sort.SliceStable(group.Pages, func(i, j int) bool {
+			return group.Pages[i].Url < group.Pages[j].Url
+		})
+
+		groups = append(groups, *group)
+	}
+
+	return groups
+}
+
+func reportTitle(runs []ReportRun) string {
+	if len(runs) == 1 {
+		return fmt.Sprintf("%s report for %s", runs[0].Entry.Tool, runs[0].Entry.Url)
+	}
+
+	return "insightly report"
+}
+
+// RenderMarkdownReport renders the runs as markdown which reads well in PR comments and wikis, i.e. GitHub
+// flavored tables with the AI summaries and the issues collapsed under `<details>`
+func RenderMarkdownReport(runs []ReportRun) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "# %s\n\n", reportTitle(runs))
+
+	builder.WriteString("| Run | Page | Tool | Performance | Errors | Warnings | Notices |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+
+	for _, run := range runs {
+		entry := run.Entry
+		severities := map[string]int{}
+
+		for _, issue := range entry.Issues {
+			severities[issue.Severity]++
+		}
+
+		score := "-"
+		if entry.Score != nil {
+			score = fmt.Sprintf("%.2f", *entry.Score)
+		}
+
+		fmt.Fprintf(&builder, "| `%s` | %s | %s | %s | %d | %d | %d |\n", entry.Id, markdownCell(entry.Url), entry.Tool, score, severities[SeverityError], severities[SeverityWarning], severities[SeverityNotice])
+	}
+
+	for _, run := range runs {
+		if len(run.Entry.Metrics) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&builder, "\n## Metrics of %s\n\n", run.Entry.Url)
+		builder.WriteString("| Metric | Value |\n| --- | --- |\n")
+
+		for _, metric := range LighthouseMetrics {
+			if value, ok := run.Entry.Metrics[metric]; ok {
+				fmt.Fprintf(&builder, "| %s | %s |\n", strings.ReplaceAll(metric, "_", " "), markdownCell(value))
+			}
+		}
+	}
+
+	for _, run := range runs {
+		if run.Summary == "" {
+			continue
+		}
+
+		fmt.Fprintf(&builder, "\n<details>\n<summary>AI summary of the %s report for %s</summary>\n\n%s\n\n</details>\n", run.Entry.Tool, run.Entry.Url, strings.TrimSpace(run.Summary))
+	}
+
+	builder.WriteString("\n## Issues\n")
+
+	criteria := groupIssuesByCriterion(runs)
+
+	if len(criteria) == 0 {
+		builder.WriteString("\nNo issues were found.\n")
+	}
+
+	for _, criterion := range criteria {
+		fmt.Fprintf(&builder, "\n### %s (%d)\n", criterion.Title, criterion.Issues)
+
+		for _, page := range criterion.Pages {
+			fmt.Fprintf(&builder, "\n<details>\n<summary>%s - %d issue(s)</summary>\n\n", page.Url, len(page.Issues))
+			builder.WriteString("| Severity | Tool | Rule | Selector | Message |\n| --- | --- | --- | --- | --- |\n")
+
+			for _, issue := range page.Issues {
+				fmt.Fprintf(&builder, "| %s | %s | %s | %s | %s |\n", issue.Severity, issue.Tool, markdownCodeCell(issue.Rule), markdownCodeCell(issue.Selector), markdownCell(issue.Message))
+			}
+
+			builder.WriteString("\n</details>\n")
+		}
+	}
+
+	return builder.String()
+}
+
+// markdownCell escapes the value so that it doesn't break out of the table cell, or get rendered as HTML
+func markdownCell(value string) string {
+	value = strings.NewReplacer("|", "\\|", "<", "&lt;", ">", "&gt;").Replace(value)
+	value = strings.ReplaceAll(value, "\r", "")
+
+	return strings.ReplaceAll(strings.TrimSpace(value), "\n", "<br>")
+}
+
+// markdownCodeCell renders the value as code in a table cell. unlike markdownCell, `<` and `>` are kept as
+// they are, since code isn't rendered as HTML and selectors use `>` for children
+func markdownCodeCell(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(strings.Join(strings.Fields(value), " "), "|", "\\|"))
+}
+
+// RenderCsvReport renders the issues of the runs as CSV, one row per issue, for tracking them in
+// spreadsheets
+func RenderCsvReport(runs []ReportRun) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	if err := writer.Write([]string{"run_id", "url", "tool", "rule", "wcag", "severity", "selector", "message", "triage", "owner"}); err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs {
+		for _, issue := range run.Entry.Issues {
+			row := []string{run.Entry.Id, run.Entry.Url, issue.Tool, issue.Rule, WcagCriterion(issue), issue.Severity, issue.Selector, issue.Message, issue.Triage, issue.Owner}
+
+			for i := range row {
+				row[i] = csvCell(row[i])
+			}
+
+			if err := writer.Write(row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return buffer.Bytes(), writer.Error()
+}
+
+// csvCell prefixes values which spreadsheets would evaluate as formulas, as the messages and selectors
+// come from the audited page
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// the subset of SARIF 2.1.0 which is needed for uploading the issues to code scanning tools, e.g. GitHub
+// code scanning. see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string          `json:"id"`
+	ShortDescription sarifMessage    `json:"shortDescription"`
+	Properties       *sarifRuleProps `json:"properties,omitempty"`
+}
+
+type sarifRuleProps struct {
+	Tags []string `json:"tags"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+var sarifInformationUris = map[string]string{
+	"pa11y":      "https://pa11y.org",
+	"lighthouse": "https://developer.chrome.com/docs/lighthouse",
+}
+
+var sarifLevels = map[string]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityNotice:  "note",
+}
+
+// RenderSarifReport renders the issues of the runs as SARIF, with a SARIF run for each of the runs. the
+// page is the location of the issues and the selector is the element within it
+func RenderSarifReport(runs []ReportRun) ([]byte, error) {
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{},
+	}
+
+	for _, run := range runs {
+		sarif := sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           run.Entry.Tool,
+				InformationUri: sarifInformationUris[run.Entry.Tool],
+				Rules:          []sarifRule{},
+			}},
+			Results: []sarifResult{},
+		}
+
+		rules := map[string]bool{}
+
+		for _, issue := range run.Entry.Issues {
+			if !rules[issue.Rule] {
+				rules[issue.Rule] = true
+
+				rule := sarifRule{Id: issue.Rule, ShortDescription: sarifMessage{Text: issue.Rule}}
+
+				if criterion := WcagCriterion(issue); criterion != "" {
+					rule.ShortDescription.Text = fmt.Sprintf("WCAG %s %s", criterion, WcagCriteria[criterion])
+					rule.Properties = &sarifRuleProps{Tags: []string{"accessibility", "wcag" + criterion}}
+				}
+
+				sarif.Tool.Driver.Rules = append(sarif.Tool.Driver.Rules, rule)
+			}
+
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{Uri: run.Entry.Url}}}
+
+			if issue.Selector != "" {
+				location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: issue.Selector, Kind: "element"}}
+			}
+
+			level, ok := sarifLevels[issue.Severity]
+			if !ok {
+				level = "warning"
+			}
+
+			sarif.Results = append(sarif.Results, sarifResult{
+				RuleId:              issue.Rule,
+				Level:               level,
+				Message:             sarifMessage{Text: issue.Message},
+				Locations:           []sarifLocation{location},
+				PartialFingerprints: map[string]string{"insightly/v1": IssueFingerprint(issue)},
+			})
+		}
+
+		log.Runs = append(log.Runs, sarif)
+	}
+
+	return json.MarshalIndent(&log, "", " ")
+}
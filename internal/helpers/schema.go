@@ -0,0 +1,464 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/utils"
+)
+
+// CurrentConfigVersion is bumped whenever the schema of the config file changes, along with a migration
+// from the previous version in configMigrations
+const CurrentConfigVersion = 1
+
+type configMigration struct {
+	// version which the migration upgrades the config file to
+	version     int
+	description string
+	migrate     func(raw map[string]any) error
+}
+
+// migrations operate on the raw JSON, as older config files don't necessarily unmarshal into the current
+// ConfigFile struct
+var configMigrations = []configMigration{
+	{
+		version:     1,
+		description: "add schema version and normalize LLM names",
+		migrate:     migrateConfigToV1,
+	},
+}
+
+// ConfigSyntaxError is returned when the config file isn't valid JSON or has values of the wrong type
+type ConfigSyntaxError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ConfigSyntaxError) Error() string {
+	if e.Line != 0 {
+		return fmt.Sprintf("config file %s is corrupted (line %d, column %d): %s. Run `config doctor` to repair it", e.Path, e.Line, e.Column, e.Err.Error())
+	}
+
+	return fmt.Sprintf("config file %s is corrupted: %s. Run `config doctor` to repair it", e.Path, e.Err.Error())
+}
+
+func (e *ConfigSyntaxError) Unwrap() error {
+	return e.Err
+}
+
+type ConfigProblem struct {
+	Message string
+	// warnings don't stop commands from running, they are only reported by `config doctor`
+	Warning bool
+	// fixable problems are repaired by `config doctor --fix`
+	Fixable bool
+}
+
+type ConfigValidationError struct {
+	Problems []ConfigProblem
+}
+
+func (e *ConfigValidationError) Error() string {
+	var messages []string
+
+	for i := range e.Problems {
+		messages = append(messages, e.Problems[i].Message)
+	}
+
+	return fmt.Sprintf("invalid config file: %s. Run `config doctor` to repair it", strings.Join(messages, "; "))
+}
+
+// parseConfigFile parses the config file, migrating it to the current version if required. the
+// descriptions of the applied migrations are returned along with the config
+func parseConfigFile(data []byte) (ConfigFile, []string, error) {
+	var raw map[string]any
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return ConfigFile{}, nil, newConfigSyntaxError(data, err)
+	}
+
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentConfigVersion {
+		return ConfigFile{}, nil, fmt.Errorf("config file %s was written by a newer version of insightly (schema version %d). Update insightly to use it", GetConfigFilePath(), version)
+	}
+
+	var applied []string
+
+	for _, migration := range configMigrations {
+		if migration.version <= version {
+			continue
+		}
+
+		if err := migration.migrate(raw); err != nil {
+			return ConfigFile{}, nil, fmt.Errorf("failed to migrate config file to version %d: %w", migration.version, err)
+		}
+
+		raw["version"] = migration.version
+		applied = append(applied, fmt.Sprintf("v%d: %s", migration.version, migration.description))
+	}
+
+	migrated, err := json.Marshal(&raw)
+	if err != nil {
+		return ConfigFile{}, nil, err
+	}
+
+	var config ConfigFile
+
+	decoder := json.NewDecoder(bytes.NewReader(migrated))
+	if err := decoder.Decode(&config); err != nil {
+		return ConfigFile{}, nil, &ConfigSyntaxError{Path: GetConfigFilePath(), Err: err}
+	}
+
+	return config, applied, nil
+}
+
+func newConfigSyntaxError(data []byte, err error) *ConfigSyntaxError {
+	configErr := &ConfigSyntaxError{Path: GetConfigFilePath(), Err: err}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		configErr.Line, configErr.Column = offsetToLineColumn(data, syntaxErr.Offset)
+	}
+
+	return configErr
+}
+
+func offsetToLineColumn(data []byte, offset int64) (int, int) {
+	line, column := 1, 1
+
+	for i := int64(0); i < offset && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
+
+func migrateConfigToV1(raw map[string]any) error {
+	if def, ok := raw["default"].(string); ok {
+		raw["default"] = strings.ToLower(strings.TrimSpace(def))
+	}
+
+	llms, _ := raw["llms"].([]any)
+
+	for _, llm := range llms {
+		if llmConfig, ok := llm.(map[string]any); ok {
+			if name, ok := llmConfig["name"].(string); ok {
+				llmConfig["name"] = strings.ToLower(strings.TrimSpace(name))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateConfig checks the config against the valid LLMs and the required fields
+func ValidateConfig(config ConfigFile) []ConfigProblem {
+	var problems []ConfigProblem
+
+	if len(config.Llms) == 0 {
+		if len(config.Profiles) == 0 {
+			problems = append(problems, ConfigProblem{Message: "no LLMs are configured, run `setup` to configure one"})
+		} else {
+			// the other profiles might be the ones which are actually used
+			name := config.loadedProfile
+			if name == "" {
+				name = DefaultProfile
+			}
+
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("profile %s has no LLMs, run `setup --profile %s` to configure one", name, name), Warning: true})
+		}
+	}
+
+	seen := make(map[Llm]bool)
+
+	for i := range config.Llms {
+		name := config.Llms[i].Name
+
+		if name == "" {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("LLM #%d has no name", i+1), Fixable: true})
+			continue
+		}
+
+		if !utils.OneOfThem(string(name), ValidLlms) {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("%s isn't a valid LLM, valid LLMs are %s", name, strings.Join(ValidLlms, ", ")), Fixable: true})
+			continue
+		}
+
+		if seen[name] {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("%s is configured more than once", name), Fixable: true})
+			continue
+		}
+
+		seen[name] = true
+
+		if config.Llms[i].ApiKeyRef != "" {
+			store, _, _ := strings.Cut(config.Llms[i].ApiKeyRef, ":")
+			if KeyStore(store) != KeyringStore && KeyStore(store) != VaultStore {
+				problems = append(problems, ConfigProblem{Message: fmt.Sprintf("API key reference %s of %s is invalid, run `config set %s` to set the key again", config.Llms[i].ApiKeyRef, name, name)})
+			}
+		}
+
+		if config.Llms[i].ApiKey == "" && config.Llms[i].ApiKeyRef == "" && config.Llms[i].ApiKeyCmd == "" && os.Getenv(GetLlmKeyEnvVar(name)) == "" {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("%s has no API key, run `config set %s` or set %s", name, name, GetLlmKeyEnvVar(name)), Warning: true})
+		}
+	}
+
+	if config.Default == "" {
+		if len(config.Llms) != 0 {
+			problems = append(problems, ConfigProblem{Message: "no default LLM is set", Fixable: true})
+		}
+	} else if !seen[config.Default] {
+		problems = append(problems, ConfigProblem{Message: fmt.Sprintf("default LLM %s isn't configured", config.Default), Fixable: true})
+	}
+
+	for _, llm := range config.Fallback {
+		if !seen[llm] {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("fallback LLM %s isn't configured", llm), Fixable: true})
+		} else if !IsSupportedLlm(llm) {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("fallback LLM %s isn't supported right now", llm), Warning: true, Fixable: true})
+		}
+	}
+
+	for i := range config.Redaction.Patterns {
+		if _, err := regexp.Compile(config.Redaction.Patterns[i].Pattern); err != nil {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("redaction pattern %q is invalid: %s", config.Redaction.Patterns[i].Name, err.Error()), Fixable: true})
+		}
+	}
+
+	for llm, pricing := range config.Pricing {
+		if !utils.OneOfThem(string(llm), ValidLlms) {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("pricing is set for %s, which isn't a valid LLM", llm), Warning: true, Fixable: true})
+		}
+
+		if pricing.InputPerMillion < 0 || pricing.OutputPerMillion < 0 {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("pricing of %s is negative", llm), Fixable: true})
+		}
+	}
+
+	if config.Profile != "" && config.Profile != DefaultProfile {
+		if _, ok := config.Profiles[config.Profile]; !ok {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("selected profile %s doesn't exist", config.Profile), Fixable: true})
+		}
+	}
+
+	for _, name := range ListProfiles(config) {
+		profile, ok := config.Profiles[name]
+		if !ok || name == config.loadedProfile {
+			continue
+		}
+
+		if name != DefaultProfile && ValidateProfileName(name) != nil {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("profile name %q is invalid, profile names can only contain lowercase letters, digits, `-` and `_`", name)})
+		}
+
+		if len(profile.Llms) == 0 {
+			problems = append(problems, ConfigProblem{Message: fmt.Sprintf("profile %s has no LLMs, run `setup --profile %s` to configure one", name, name), Warning: true})
+			continue
+		}
+
+		for _, problem := range ValidateConfig(profileAsConfig(profile)) {
+			problem.Message = fmt.Sprintf("profile %s: %s", name, problem.Message)
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems
+}
+
+// profileAsConfig returns a config which only has the fields of the profile, so that it can be validated
+// and repaired like the top-level fields
+func profileAsConfig(profile Profile) ConfigFile {
+	config := ConfigFile{}
+	setCurrentProfile(&config, profile)
+
+	return config
+}
+
+// RepairConfig fixes the fixable problems reported by ValidateConfig
+func RepairConfig(config ConfigFile) ConfigFile {
+	var llms []LlmConfig
+	seen := make(map[Llm]bool)
+
+	for i := range config.Llms {
+		name := config.Llms[i].Name
+
+		if name == "" || !utils.OneOfThem(string(name), ValidLlms) || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		llms = append(llms, config.Llms[i])
+	}
+
+	config.Llms = llms
+
+	if !seen[config.Default] {
+		config.Default = ""
+
+		if len(config.Llms) != 0 {
+			config.Default = config.Llms[0].Name
+		}
+	}
+
+	var fallback []Llm
+
+	for _, llm := range config.Fallback {
+		if seen[llm] && IsSupportedLlm(llm) && !utils.OneOfThem(llm, fallback) {
+			fallback = append(fallback, llm)
+		}
+	}
+
+	config.Fallback = fallback
+
+	var patterns []RedactionPattern
+
+	for i := range config.Redaction.Patterns {
+		if _, err := regexp.Compile(config.Redaction.Patterns[i].Pattern); err == nil {
+			patterns = append(patterns, config.Redaction.Patterns[i])
+		}
+	}
+
+	config.Redaction.Patterns = patterns
+
+	for llm, pricing := range config.Pricing {
+		if !utils.OneOfThem(string(llm), ValidLlms) || pricing.InputPerMillion < 0 || pricing.OutputPerMillion < 0 {
+			delete(config.Pricing, llm)
+		}
+	}
+
+	if _, ok := config.Profiles[config.Profile]; !ok {
+		config.Profile = ""
+	}
+
+	if len(config.Profiles) != 0 {
+		profiles := make(map[string]Profile, len(config.Profiles))
+
+		for name, profile := range config.Profiles {
+			if name == config.loadedProfile {
+				profiles[name] = profile
+				continue
+			}
+
+			repaired := RepairConfig(profileAsConfig(profile))
+			profiles[name] = currentProfile(repaired)
+		}
+
+		config.Profiles = profiles
+	}
+
+	return config
+}
+
+// HasConfigErrors reports whether any of the problems stop commands from running
+func HasConfigErrors(problems []ConfigProblem) bool {
+	for i := range problems {
+		if !problems[i].Warning {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getConfigBackupFilePath() string {
+	return GetConfigFilePath() + ".bak"
+}
+
+// backupConfigFile copies the current config file to `<config>.bak` before it is overwritten, as long as
+// it is valid JSON, so that `config doctor` can restore it if the config file gets corrupted. plaintext
+// API keys are left out of the backup, if the current config file can't be backed up then the existing
+// backup is rewritten without them instead, as it might have been written by an older version
+func backupConfigFile() error {
+	data, err := os.ReadFile(GetConfigFilePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err != nil || !json.Valid(data) {
+		data, err = os.ReadFile(getConfigBackupFilePath())
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	var config any
+	if err := json.Unmarshal(data, &config); err != nil {
+		// a backup which isn't valid JSON can't be restored either
+		return os.Remove(getConfigBackupFilePath())
+	}
+
+	stripApiKeys(config)
+
+	data, err = json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(getConfigBackupFilePath(), data, 0600)
+}
+
+// stripApiKeys removes the `api_key` fields of the LLMs, including the ones of the profiles, from the
+// decoded config file
+func stripApiKeys(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		delete(value, "api_key")
+
+		for _, child := range value {
+			stripApiKeys(child)
+		}
+	case []any:
+		for _, child := range value {
+			stripApiKeys(child)
+		}
+	}
+}
+
+// RestoreConfigBackup replaces the config file with its backup, if the backup exists and can be parsed
+func RestoreConfigBackup() (bool, error) {
+	data, err := os.ReadFile(getConfigBackupFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if _, _, err := parseConfigFile(data); err != nil {
+		return false, nil
+	}
+
+	return true, os.WriteFile(GetConfigFilePath(), data, 0600)
+}
+
+// ReadRawConfigFile parses the config file without validating it, returning the migrations which had to
+// be applied to it. it is used by `config doctor`
+func ReadRawConfigFile() (ConfigFile, []string, error) {
+	data, err := os.ReadFile(GetConfigFilePath())
+	if err != nil {
+		return ConfigFile{}, nil, err
+	}
+
+	return parseConfigFile(data)
+}
@@ -0,0 +1,273 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/charmbracelet/huh"
+	"github.com/zalando/go-keyring"
+)
+
+type KeyStore string
+
+var (
+	KeyringStore   KeyStore = "keyring"
+	VaultStore     KeyStore = "vault"
+	PlaintextStore KeyStore = "plaintext"
+)
+
+const keyringService = "insightly"
+
+// vault passphrase is asked only once per run
+var vaultPassphrase string
+
+func GetVaultFilePath() string {
+	return filepath.Join(filepath.Dir(GetConfigFilePath()), "vault.age")
+}
+
+// GetKeyStore returns the store which is used for saving new API keys. `INSIGHTLY_KEY_STORE` can be used
+// for forcing a store, otherwise the OS keyring is used if it is available and the encrypted vault if not
+func GetKeyStore() KeyStore {
+	switch KeyStore(strings.ToLower(os.Getenv("INSIGHTLY_KEY_STORE"))) {
+	case KeyringStore:
+		return KeyringStore
+	case VaultStore:
+		return VaultStore
+	case PlaintextStore:
+		return PlaintextStore
+	}
+
+	if isKeyringAvailable() {
+		return KeyringStore
+	}
+
+	return VaultStore
+}
+
+func isKeyringAvailable() bool {
+	_, err := keyring.Get(keyringService, "__probe__")
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// StoreApiKey saves the API key under the given account in the key store and returns the reference to it,
+// which is stored in the config file instead of the key itself
+func StoreApiKey(account string, key string) (string, error) {
+	store := GetKeyStore()
+
+	switch store {
+	case KeyringStore:
+		if err := keyring.Set(keyringService, account, key); err != nil {
+			return "", err
+		}
+	case VaultStore:
+		vault, err := readVault()
+		if err != nil {
+			return "", err
+		}
+
+		vault[account] = key
+
+		if err := writeVault(vault); err != nil {
+			return "", err
+		}
+	default:
+		return "", nil
+	}
+
+	return fmt.Sprintf("%s:%s", store, account), nil
+}
+
+// ResolveApiKeyRef returns the API key which the reference points to
+func ResolveApiKeyRef(ref string) (string, error) {
+	store, name, found := strings.Cut(ref, ":")
+	if !found {
+		return "", fmt.Errorf("invalid API key reference %s", ref)
+	}
+
+	switch KeyStore(store) {
+	case KeyringStore:
+		key, err := keyring.Get(keyringService, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key of %s from the keyring: %w", name, err)
+		}
+
+		return key, nil
+	case VaultStore:
+		vault, err := readVault()
+		if err != nil {
+			return "", err
+		}
+
+		key, ok := vault[name]
+		if !ok {
+			return "", fmt.Errorf("API key of %s can't be found in the vault", name)
+		}
+
+		return key, nil
+	default:
+		return "", fmt.Errorf("invalid API key reference %s", ref)
+	}
+}
+
+// DeleteApiKeyRef removes the API key which the reference points to from its store
+func DeleteApiKeyRef(ref string) error {
+	store, name, found := strings.Cut(ref, ":")
+	if !found {
+		return nil
+	}
+
+	switch KeyStore(store) {
+	case KeyringStore:
+		if err := keyring.Delete(keyringService, name); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return err
+		}
+	case VaultStore:
+		vault, err := readVault()
+		if err != nil {
+			return err
+		}
+
+		delete(vault, name)
+
+		return writeVault(vault)
+	}
+
+	return nil
+}
+
+func getVaultPassphrase(confirm bool) (string, error) {
+	if vaultPassphrase != "" {
+		return vaultPassphrase, nil
+	}
+
+	if passphrase := os.Getenv("INSIGHTLY_VAULT_PASSPHRASE"); passphrase != "" {
+		vaultPassphrase = passphrase
+		return vaultPassphrase, nil
+	}
+
+	passphrase, err := askPassphrase("input the passphrase of your API key vault", confirm)
+	if err != nil {
+		return "", err
+	}
+
+	vaultPassphrase = passphrase
+
+	return vaultPassphrase, nil
+}
+
+// askPassphrase prompts for a passphrase, asking for it twice if confirm is set
+func askPassphrase(title string, confirm bool) (string, error) {
+	var passphrase, confirmation string
+
+	fields := []huh.Field{
+		huh.NewInput().Title(title).Value(&passphrase).EchoMode(huh.EchoModePassword).Validate(func(s string) error {
+			if len(s) == 0 {
+				return errors.New("input a passphrase")
+			}
+
+			return nil
+		}),
+	}
+
+	if confirm {
+		fields = append(fields, huh.NewInput().Title("confirm the passphrase").Value(&confirmation).EchoMode(huh.EchoModePassword).Validate(func(s string) error {
+			if s != passphrase {
+				return errors.New("passphrases don't match")
+			}
+
+			return nil
+		}))
+	}
+
+	if err := RunForm(huh.NewForm(huh.NewGroup(fields...))); err != nil {
+		return "", err
+	}
+
+	return passphrase, nil
+}
+
+func readVault() (map[string]string, error) {
+	vault := make(map[string]string)
+
+	encrypted, err := os.ReadFile(GetVaultFilePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return vault, nil
+		}
+
+		return nil, err
+	}
+
+	passphrase, err := getVaultPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(encrypted), identity)
+	if err != nil {
+		vaultPassphrase = ""
+		return nil, fmt.Errorf("failed to decrypt the API key vault, check your passphrase: %w", err)
+	}
+
+	decrypted, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(decrypted, &vault); err != nil {
+		return nil, err
+	}
+
+	return vault, nil
+}
+
+func writeVault(vault map[string]string) error {
+	_, err := os.Stat(GetVaultFilePath())
+	passphrase, err := getVaultPassphrase(errors.Is(err, os.ErrNotExist))
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := json.Marshal(&vault)
+	if err != nil {
+		return err
+	}
+
+	encrypted := &bytes.Buffer{}
+
+	writer, err := age.Encrypt(encrypted, recipient)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(decrypted); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	if err := ensureDir(filepath.Dir(GetVaultFilePath())); err != nil {
+		return err
+	}
+
+	return os.WriteFile(GetVaultFilePath(), encrypted.Bytes(), 0600)
+}
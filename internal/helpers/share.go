@@ -0,0 +1,480 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+type ExportKeyMode string
+
+var (
+	// API keys are left out, only `api_key_cmd`s are exported
+	ExportWithoutKeys ExportKeyMode = "none"
+	// API keys are exported along with the config, which is encrypted using a passphrase
+	ExportEncryptedKeys ExportKeyMode = "encrypted"
+	ExportPlaintextKeys ExportKeyMode = "plaintext"
+)
+
+var ValidExportKeyModes = []string{string(ExportWithoutKeys), string(ExportEncryptedKeys), string(ExportPlaintextKeys)}
+
+// ConfigConflict is a field which is set differently in the current config and the imported one
+type ConfigConflict struct {
+	Profile string
+	Field   string
+	Current string
+	Import  string
+	// set when the imported value is an `api_key_cmd`, which runs on this machine, so it has to be
+	// confirmed explicitly instead of being resolved by `--on-conflict` or `--yes`
+	Command string
+	// set when the imported value weakens the protections of this machine, e.g. disabling redaction, along
+	// with what it leads to. like Command, it has to be confirmed explicitly
+	Warning string
+}
+
+// ResolveConflictFunc returns whether the imported value replaces the current one
+type ResolveConflictFunc func(conflict ConfigConflict) (bool, error)
+
+func getSharePassphrase(title string, confirm bool) (string, error) {
+	if passphrase := os.Getenv("INSIGHTLY_EXPORT_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+
+	return askPassphrase(title, confirm)
+}
+
+// ExportConfig renders the config file, with all of its profiles, as a config file which can be shared
+// with others. the selected profile isn't exported, as it is a personal choice
+func ExportConfig(config ConfigFile, mode ExportKeyMode) ([]byte, error) {
+	config = storeProfile(config)
+	config.Profile = ""
+	config.Version = CurrentConfigVersion
+
+	if err := exportLlmKeys(&config.Llms, DefaultProfile, mode); err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string]Profile, len(config.Profiles))
+
+	for name, profile := range config.Profiles {
+		if err := exportLlmKeys(&profile.Llms, name, mode); err != nil {
+			return nil, err
+		}
+
+		profiles[name] = profile
+	}
+
+	if len(profiles) != 0 {
+		config.Profiles = profiles
+	}
+
+	data, err := json.MarshalIndent(&config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != ExportEncryptedKeys {
+		return data, nil
+	}
+
+	passphrase, err := getSharePassphrase("input a passphrase for encrypting the exported config", true)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := &bytes.Buffer{}
+	armored := armor.NewWriter(encrypted)
+
+	writer, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+
+	return encrypted.Bytes(), nil
+}
+
+// exportLlmKeys replaces the references to the key store, which are only valid on this machine, with the
+// keys themselves or leaves them out. a copy of the LLM configs is made, as they are shared with the config
+func exportLlmKeys(llms *[]LlmConfig, profile string, mode ExportKeyMode) error {
+	exported := make([]LlmConfig, len(*llms))
+
+	for i, llmConfig := range *llms {
+		key := llmConfig.ApiKey
+
+		if llmConfig.ApiKeyRef != "" && mode != ExportWithoutKeys {
+			resolved, err := ResolveApiKeyRef(llmConfig.ApiKeyRef)
+			if err != nil {
+				return fmt.Errorf("failed to export API key of %s from %s profile: %w", llmConfig.Name, profile, err)
+			}
+
+			key = resolved
+		}
+
+		llmConfig.ApiKeyRef = ""
+		llmConfig.ApiKey = ""
+
+		// `api_key_cmd` takes precedence over the stored key, so there is no need to export both
+		if mode != ExportWithoutKeys && llmConfig.ApiKeyCmd == "" {
+			llmConfig.ApiKey = key
+		}
+
+		exported[i] = llmConfig
+	}
+
+	*llms = exported
+
+	return nil
+}
+
+// ReadExportedConfig parses a config written by ExportConfig, decrypting it if required
+func ReadExportedConfig(data []byte) (ConfigFile, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		passphrase, err := getSharePassphrase("input the passphrase of the exported config", false)
+		if err != nil {
+			return ConfigFile{}, err
+		}
+
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return ConfigFile{}, err
+		}
+
+		reader, err := age.Decrypt(armor.NewReader(bytes.NewReader(bytes.TrimSpace(data))), identity)
+		if err != nil {
+			return ConfigFile{}, fmt.Errorf("failed to decrypt the exported config, check the passphrase: %w", err)
+		}
+
+		if data, err = io.ReadAll(reader); err != nil {
+			return ConfigFile{}, err
+		}
+	}
+
+	config, _, err := parseConfigFile(data)
+	if err != nil {
+		var syntaxErr *ConfigSyntaxError
+		if errors.As(err, &syntaxErr) {
+			return ConfigFile{}, fmt.Errorf("exported config is corrupted: %w", syntaxErr.Err)
+		}
+
+		return ConfigFile{}, err
+	}
+
+	if problems := ValidateConfig(config); HasConfigErrors(problems) {
+		var messages []string
+
+		for i := range problems {
+			if !problems[i].Warning {
+				messages = append(messages, problems[i].Message)
+			}
+		}
+
+		return ConfigFile{}, fmt.Errorf("exported config is invalid: %s", strings.Join(messages, "; "))
+	}
+
+	// references to the key store are only valid on the machine which exported the config
+	for i := range config.Llms {
+		config.Llms[i].ApiKeyRef = ""
+	}
+
+	for name := range config.Profiles {
+		for i := range config.Profiles[name].Llms {
+			config.Profiles[name].Llms[i].ApiKeyRef = ""
+		}
+	}
+
+	config.Profile = ""
+
+	return config, nil
+}
+
+// MergeConfig merges the imported config into the current one, profile by profile. resolve is called for
+// each of the fields which are set in both of them with different values. the returned changes describe
+// what was added or replaced
+func MergeConfig(current ConfigFile, imported ConfigFile, resolve ResolveConflictFunc) (ConfigFile, []string, error) {
+	current = storeProfile(current)
+
+	var changes []string
+
+	for _, name := range ListProfiles(imported) {
+		importedProfile, ok := imported.Profiles[name]
+		if name == DefaultProfile {
+			importedProfile, ok = currentProfile(imported), true
+		}
+
+		if !ok {
+			continue
+		}
+
+		currentProfileConfig, exists := current.Profiles[name]
+		if name == DefaultProfile {
+			currentProfileConfig, exists = currentProfile(current), true
+		}
+
+		if !exists {
+			changes = append(changes, fmt.Sprintf("added %s profile", name))
+		}
+
+		merged, profileChanges, err := mergeProfile(name, currentProfileConfig, importedProfile, resolve)
+		if err != nil {
+			return ConfigFile{}, nil, err
+		}
+
+		changes = append(changes, profileChanges...)
+
+		if name == DefaultProfile {
+			setCurrentProfile(&current, merged)
+			continue
+		}
+
+		if current.Profiles == nil {
+			current.Profiles = make(map[string]Profile)
+		}
+
+		current.Profiles[name] = merged
+	}
+
+	for _, pattern := range imported.Redaction.Patterns {
+		found := false
+
+		for i := range current.Redaction.Patterns {
+			if current.Redaction.Patterns[i].Name == pattern.Name {
+				found = true
+			}
+		}
+
+		if !found {
+			current.Redaction.Patterns = append(current.Redaction.Patterns, pattern)
+			changes = append(changes, fmt.Sprintf("added %s redaction pattern", pattern.Name))
+		}
+	}
+
+	if imported.Redaction.Disabled && !current.Redaction.Disabled {
+		replace, err := resolve(ConfigConflict{
+			Field:   "redaction",
+			Current: "enabled",
+			Import:  "disabled",
+			Warning: "emails, tokens and the other sensitive values of the reports are sent to the LLMs as they are",
+		})
+		if err != nil {
+			return ConfigFile{}, nil, err
+		}
+
+		if replace {
+			current.Redaction.Disabled = true
+			changes = append(changes, "disabled redaction")
+		}
+	}
+
+	for llm, pricing := range imported.Pricing {
+		currentPricing, ok := current.Pricing[llm]
+
+		if ok && currentPricing != pricing {
+			replace, err := resolve(ConfigConflict{
+				Field:   fmt.Sprintf("pricing of %s", llm),
+				Current: fmt.Sprintf("$%g/$%g", currentPricing.InputPerMillion, currentPricing.OutputPerMillion),
+				Import:  fmt.Sprintf("$%g/$%g", pricing.InputPerMillion, pricing.OutputPerMillion),
+			})
+			if err != nil {
+				return ConfigFile{}, nil, err
+			}
+
+			if !replace {
+				continue
+			}
+		} else if ok {
+			continue
+		}
+
+		if current.Pricing == nil {
+			current.Pricing = make(map[Llm]LlmPricing)
+		}
+
+		current.Pricing[llm] = pricing
+		changes = append(changes, fmt.Sprintf("set pricing of %s", llm))
+	}
+
+	return current, changes, nil
+}
+
+func mergeProfile(name string, current Profile, imported Profile, resolve ResolveConflictFunc) (Profile, []string, error) {
+	var changes []string
+
+	llms := append([]LlmConfig{}, current.Llms...)
+
+	for _, importedLlm := range imported.Llms {
+		index := -1
+
+		for i := range llms {
+			if llms[i].Name == importedLlm.Name {
+				index = i
+			}
+		}
+
+		if index == -1 {
+			if importedLlm.ApiKeyCmd != "" {
+				approved, err := resolve(ConfigConflict{Profile: name, Field: fmt.Sprintf("API key of %s", importedLlm.Name), Current: "no key", Import: describeLlmKey(importedLlm), Command: importedLlm.ApiKeyCmd})
+				if err != nil {
+					return Profile{}, nil, err
+				}
+
+				if !approved {
+					importedLlm.ApiKeyCmd = ""
+					llms = append(llms, importedLlm)
+					changes = append(changes, fmt.Sprintf("added %s to %s profile without its api_key_cmd", importedLlm.Name, name))
+					continue
+				}
+			}
+
+			llms = append(llms, importedLlm)
+			changes = append(changes, fmt.Sprintf("added %s to %s profile", importedLlm.Name, name))
+			continue
+		}
+
+		// an imported LLM without a key doesn't replace the key which is already configured
+		if importedLlm.ApiKey == "" && importedLlm.ApiKeyCmd == "" {
+			continue
+		}
+
+		if importedLlm.ApiKeyCmd != "" && importedLlm.ApiKeyCmd == llms[index].ApiKeyCmd {
+			continue
+		}
+
+		if importedLlm.ApiKey != "" && llms[index].ApiKeyCmd == "" {
+			key := llms[index].ApiKey
+			if llms[index].ApiKeyRef != "" {
+				key, _ = ResolveApiKeyRef(llms[index].ApiKeyRef)
+			}
+
+			if key == importedLlm.ApiKey {
+				continue
+			}
+		}
+
+		replace, err := resolve(ConfigConflict{Profile: name, Field: fmt.Sprintf("API key of %s", importedLlm.Name), Current: describeLlmKey(llms[index]), Import: describeLlmKey(importedLlm), Command: importedLlm.ApiKeyCmd})
+		if err != nil {
+			return Profile{}, nil, err
+		}
+
+		if !replace {
+			continue
+		}
+
+		if err := ReplaceLlmKey(&llms[index], importedLlm.ApiKey, importedLlm.ApiKeyCmd); err != nil {
+			return Profile{}, nil, err
+		}
+
+		changes = append(changes, fmt.Sprintf("replaced API key of %s in %s profile", importedLlm.Name, name))
+	}
+
+	current.Llms = llms
+
+	if imported.Default != "" && imported.Default != current.Default {
+		replace := current.Default == ""
+
+		if !replace {
+			var err error
+
+			replace, err = resolve(ConfigConflict{Profile: name, Field: "default LLM", Current: string(current.Default), Import: string(imported.Default)})
+			if err != nil {
+				return Profile{}, nil, err
+			}
+		}
+
+		if replace {
+			current.Default = imported.Default
+			changes = append(changes, fmt.Sprintf("set default LLM of %s profile to %s", name, imported.Default))
+		}
+	}
+
+	if len(imported.Fallback) != 0 && !reflect.DeepEqual(imported.Fallback, current.Fallback) {
+		replace := len(current.Fallback) == 0
+
+		if !replace {
+			var err error
+
+			replace, err = resolve(ConfigConflict{Profile: name, Field: "fallback chain", Current: joinLlms(current.Fallback), Import: joinLlms(imported.Fallback)})
+			if err != nil {
+				return Profile{}, nil, err
+			}
+		}
+
+		if replace {
+			current.Fallback = imported.Fallback
+			changes = append(changes, fmt.Sprintf("set fallback chain of %s profile to %s", name, joinLlms(imported.Fallback)))
+		}
+	}
+
+	if !reflect.DeepEqual(imported.Audit, AuditDefaults{}) && !reflect.DeepEqual(imported.Audit, current.Audit) {
+		replace := reflect.DeepEqual(current.Audit, AuditDefaults{})
+
+		if !replace {
+			var err error
+
+			replace, err = resolve(ConfigConflict{Profile: name, Field: "audit defaults", Current: describeAuditDefaults(current.Audit), Import: describeAuditDefaults(imported.Audit)})
+			if err != nil {
+				return Profile{}, nil, err
+			}
+		}
+
+		if replace {
+			current.Audit = imported.Audit
+			changes = append(changes, fmt.Sprintf("set audit defaults of %s profile", name))
+		}
+	}
+
+	return current, changes, nil
+}
+
+func describeLlmKey(llmConfig LlmConfig) string {
+	switch {
+	case llmConfig.ApiKeyCmd != "":
+		return fmt.Sprintf("command `%s`", llmConfig.ApiKeyCmd)
+	case llmConfig.ApiKeyRef != "":
+		store, _, _ := strings.Cut(llmConfig.ApiKeyRef, ":")
+		return fmt.Sprintf("key from %s", store)
+	case llmConfig.ApiKey != "":
+		return "key from the imported config"
+	default:
+		return "no key"
+	}
+}
+
+func describeAuditDefaults(audit AuditDefaults) string {
+	data, _ := json.Marshal(&audit)
+	return string(data)
+}
+
+func joinLlms(llms []Llm) string {
+	var names []string
+
+	for _, llm := range llms {
+		names = append(names, string(llm))
+	}
+
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,473 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// runs, pages, issues and metrics are stored in a SQLite database for listing, searching and querying
+// them without reading each of the history entries. the history entries are still the source of truth,
+// so the database is rebuilt from them if it goes missing
+//
+// each migration upgrades the schema by one version, which is tracked via `PRAGMA user_version`
+var storeMigrations = []string{
+	`CREATE TABLE pages (
+		id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL UNIQUE,
+		host TEXT NOT NULL
+	);
+
+	CREATE TABLE runs (
+		id TEXT PRIMARY KEY,
+		page_id INTEGER NOT NULL REFERENCES pages(id),
+		url TEXT NOT NULL,
+		tool TEXT NOT NULL,
+		llm TEXT NOT NULL DEFAULT '',
+		score REAL,
+		issues INTEGER NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		previous TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+
+	CREATE INDEX runs_page_tool ON runs(page_id, tool, created_at);
+
+	CREATE TABLE issues (
+		run_id TEXT NOT NULL REFERENCES runs(id),
+		fingerprint TEXT NOT NULL,
+		tool TEXT NOT NULL,
+		rule TEXT NOT NULL,
+		message TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		selector TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		first_seen TEXT,
+		fixed_at TEXT
+	);
+
+	CREATE INDEX issues_run ON issues(run_id);
+	CREATE INDEX issues_rule ON issues(rule);
+
+	CREATE TABLE metrics (
+		run_id TEXT NOT NULL REFERENCES runs(id),
+		name TEXT NOT NULL,
+		value REAL,
+		raw TEXT NOT NULL,
+		PRIMARY KEY (run_id, name)
+	);
+
+	CREATE VIEW latest_runs AS
+		SELECT * FROM runs r
+		WHERE r.created_at = (SELECT MAX(created_at) FROM runs WHERE page_id = r.page_id AND tool = r.tool);`,
+	`ALTER TABLE issues ADD COLUMN triage TEXT NOT NULL DEFAULT '';
+	ALTER TABLE issues ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+}
+
+// times are stored in UTC with a fixed width, so that they sort as text and work with SQLite's date
+// functions
+const storeTimeFormat = "2006-01-02T15:04:05.000Z"
+
+var store *sql.DB
+
+func GetStoreFilePath() string {
+	return filepath.Join(GetDataDirPath(), "insightly.db")
+}
+
+// openStore opens the database, creating it and upgrading its schema if needed. the connection is
+// reused for the rest of the run
+func openStore() (*sql.DB, error) {
+	if store != nil {
+		return store, nil
+	}
+
+	if err := ensureDir(GetDataDirPath()); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+GetStoreFilePath()+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	// a single connection avoids `database is locked` errors between the connections of the pool
+	db.SetMaxOpenConns(1)
+
+	if err := migrateStore(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", GetStoreFilePath(), err)
+	}
+
+	store = db
+
+	return store, nil
+}
+
+func migrateStore(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(storeMigrations) {
+		return fmt.Errorf("the database was created by a newer version of insightly (schema version %d)", version)
+	}
+
+	for ; version < len(storeMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(storeMigrations[version]); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatStoreTime(t time.Time) string {
+	return t.UTC().Format(storeTimeFormat)
+}
+
+func formatStoreTimePointer(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return formatStoreTime(*t)
+}
+
+// storeHistoryEntry inserts the entry into the database, replacing it if it was already stored
+func storeHistoryEntry(tx *sql.Tx, entry HistoryEntry, summary string) error {
+	if err := deleteStoredHistoryEntry(tx, entry.Id); err != nil {
+		return err
+	}
+
+	pageUrl := normalizeHistoryUrl(entry.Url)
+
+	host := pageUrl
+	if parsedUrl, err := url.Parse("https://" + pageUrl); err == nil && parsedUrl.Host != "" {
+		host = parsedUrl.Host
+	}
+
+	if _, err := tx.Exec("INSERT INTO pages (url, host) VALUES (?, ?) ON CONFLICT (url) DO NOTHING", pageUrl, host); err != nil {
+		return err
+	}
+
+	var pageId int64
+	if err := tx.QueryRow("SELECT id FROM pages WHERE url = ?", pageUrl).Scan(&pageId); err != nil {
+		return err
+	}
+
+	var score any
+	if entry.Score != nil {
+		score = *entry.Score
+	}
+
+	_, err := tx.Exec(
+		"INSERT INTO runs (id, page_id, url, tool, llm, score, issues, summary, previous, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Id, pageId, entry.Url, entry.Tool, string(entry.Llm), score, len(entry.Issues), summary, entry.Previous, formatStoreTime(entry.CreatedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range append(append([]Issue{}, entry.Issues...), entry.Fixed...) {
+		_, err := tx.Exec(
+			"INSERT INTO issues (run_id, fingerprint, tool, rule, message, severity, selector, status, first_seen, fixed_at, triage, owner) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			entry.Id, IssueFingerprint(issue), issue.Tool, issue.Rule, issue.Message, issue.Severity, issue.Selector, issue.Status, formatStoreTimePointer(issue.FirstSeen), formatStoreTimePointer(issue.FixedAt), issue.Triage, issue.Owner,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	for name, raw := range entry.Metrics {
+		var value any
+		if parsed, ok := parseTrendMetric(raw); ok {
+			value = parsed
+		}
+
+		if _, err := tx.Exec("INSERT INTO metrics (run_id, name, value, raw) VALUES (?, ?, ?, ?)", entry.Id, name, value, raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteStoredHistoryEntry(tx *sql.Tx, id string) error {
+	for _, query := range []string{"DELETE FROM issues WHERE run_id = ?", "DELETE FROM metrics WHERE run_id = ?", "DELETE FROM runs WHERE id = ?"} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateStore runs the update in a transaction
+func updateStore(update func(tx *sql.Tx) error) error {
+	db, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := update(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// listStoredHistoryEntries returns the runs matching the condition, the most recent one first
+func listStoredHistoryEntries(condition string, args ...any) ([]HistoryIndexEntry, error) {
+	db, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, url, tool, llm, score, issues, summary != '', created_at FROM runs"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+
+	rows, err := db.Query(query+" ORDER BY created_at DESC, id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryIndexEntry{}
+
+	for rows.Next() {
+		var entry HistoryIndexEntry
+		var llm string
+		var score sql.NullFloat64
+		var createdAt string
+
+		if err := rows.Scan(&entry.Id, &entry.Url, &entry.Tool, &llm, &score, &entry.Issues, &entry.HasSummary, &createdAt); err != nil {
+			return nil, err
+		}
+
+		entry.Llm = Llm(llm)
+
+		if score.Valid {
+			entry.Score = &score.Float64
+		}
+
+		entry.CreatedAt, err = time.Parse(storeTimeFormat, createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// RebuildHistoryStore recreates the database out of the history entries
+func RebuildHistoryStore() error {
+	dirs, err := os.ReadDir(GetHistoryDirPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return updateStore(func(tx *sql.Tx) error {
+		for _, table := range []string{"issues", "metrics", "runs", "pages"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				return err
+			}
+		}
+
+		for _, dir := range dirs {
+			if !dir.IsDir() {
+				continue
+			}
+
+			entry, summary, err := readHistoryEntry(dir.Name())
+			if err != nil {
+				continue
+			}
+
+			entry.Id = dir.Name()
+
+			if err := storeHistoryEntry(tx, entry, summary); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// QueryResult is the result of a query, with each of the values converted to a string, a number or nil
+type QueryResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// CannedQuery is one of the reports which `query --report` runs
+type CannedQuery struct {
+	Name        string
+	Description string
+	Query       string
+}
+
+var CannedQueries = []CannedQuery{
+	{
+		Name:        "top-rules",
+		Description: "Rules which fail most often in the latest run of each page",
+		Query: `SELECT i.tool, i.rule, COUNT(*) AS occurrences, COUNT(DISTINCT r.page_id) AS pages
+FROM issues i JOIN latest_runs r ON r.id = i.run_id
+WHERE i.status != 'fixed'
+GROUP BY i.tool, i.rule
+ORDER BY occurrences DESC, pages DESC
+LIMIT 20`,
+	},
+	{
+		Name:        "worst-pages",
+		Description: "Pages with the most issues and the lowest lighthouse performance score in their latest runs",
+		Query: `SELECT p.url,
+	SUM(r.issues) AS issues,
+	MAX(CASE WHEN r.tool = 'lighthouse' THEN ROUND(r.score, 2) END) AS performance,
+	MAX(r.created_at) AS last_run
+FROM latest_runs r JOIN pages p ON p.id = r.page_id
+GROUP BY p.id
+ORDER BY issues DESC, performance ASC
+LIMIT 20`,
+	},
+	{
+		Name:        "oldest-issues",
+		Description: "Issues which have been open for the longest time",
+		Query: `SELECT p.url, i.tool, i.rule, i.severity, i.first_seen,
+	CAST(julianday('now') - julianday(i.first_seen) AS INTEGER) AS days_open
+FROM issues i JOIN latest_runs r ON r.id = i.run_id JOIN pages p ON p.id = r.page_id
+WHERE i.status != 'fixed' AND i.first_seen IS NOT NULL
+ORDER BY i.first_seen ASC
+LIMIT 20`,
+	},
+	{
+		Name:        "time-to-fix",
+		Description: "Average number of days it took to fix the issues of each rule",
+		Query: `SELECT tool, rule, COUNT(*) AS fixed,
+	ROUND(AVG(julianday(fixed_at) - julianday(first_seen)), 1) AS avg_days_to_fix
+FROM issues
+WHERE status = 'fixed' AND first_seen IS NOT NULL
+GROUP BY tool, rule
+ORDER BY fixed DESC
+LIMIT 20`,
+	},
+	{
+		Name:        "owners",
+		Description: "Open issues in the latest run of each page by the owner they were assigned to during triage",
+		Query: `SELECT CASE WHEN i.owner = '' THEN '(unassigned)' ELSE i.owner END AS owner,
+	COUNT(*) AS issues,
+	SUM(i.triage = 'fix') AS to_fix,
+	COUNT(DISTINCT r.page_id) AS pages
+FROM issues i JOIN latest_runs r ON r.id = i.run_id
+WHERE i.status != 'fixed'
+GROUP BY i.owner
+ORDER BY issues DESC`,
+	},
+}
+
+func FindCannedQuery(name string) (CannedQuery, bool) {
+	for _, query := range CannedQueries {
+		if query.Name == name {
+			return query, true
+		}
+	}
+
+	return CannedQuery{}, false
+}
+
+// QueryStore runs a read-only query against the database
+func QueryStore(query string) (QueryResult, error) {
+	if err := ensureHistoryStore(); err != nil {
+		return QueryResult{}, err
+	}
+
+	db, err := openStore()
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer conn.Close()
+
+	// queries are only meant for reading, writing would get the database out of sync with the history
+	if _, err := conn.ExecContext(context.Background(), "PRAGMA query_only = ON"); err != nil {
+		return QueryResult{}, err
+	}
+	defer conn.ExecContext(context.Background(), "PRAGMA query_only = OFF")
+
+	rows, err := conn.QueryContext(context.Background(), query)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return QueryResult{}, err
+	}
+
+	result := QueryResult{Columns: columns, Rows: [][]any{}}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return QueryResult{}, err
+		}
+
+		for i := range values {
+			if bytes, ok := values[i].([]byte); ok {
+				values[i] = string(bytes)
+			}
+		}
+
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return QueryResult{}, err
+	}
+
+	return result, nil
+}
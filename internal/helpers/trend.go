@@ -0,0 +1,202 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// default tolerance of the regressions, in percent
+const DefaultRegressionTolerance = 5.0
+
+type TrendMetric struct {
+	// key of the metric in the history entry, see HistoryEntry.Metrics
+	Key   string
+	Label string
+	// the score gets better as it goes up, whereas the timings get better as they go down
+	HigherIsBetter bool
+}
+
+// TrendMetrics are the lighthouse metrics which are plotted by `trend`
+var TrendMetrics = []TrendMetric{
+	{Key: "first_contentful_paint", Label: "first contentful paint"},
+	{Key: "largest_contentful_paint", Label: "largest contentful paint"},
+	{Key: "speed_index", Label: "speed index"},
+	{Key: "total_blocking_time", Label: "total blocking time"},
+	{Key: "performance", Label: "performance", HigherIsBetter: true},
+	{Key: "accessibility", Label: "accessibility", HigherIsBetter: true},
+	{Key: "best_practices", Label: "best practices", HigherIsBetter: true},
+	{Key: "seo", Label: "seo", HigherIsBetter: true},
+	{Key: "pwa", Label: "pwa", HigherIsBetter: true},
+}
+
+// IsScoreMetric reports whether the metric is a score out of 100, i.e. the score of a category, rather
+// than a timing
+func IsScoreMetric(key string) bool {
+	for _, metric := range TrendMetrics {
+		if metric.Key == key {
+			return metric.HigherIsBetter
+		}
+	}
+
+	return false
+}
+
+type TrendPoint struct {
+	Id        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// timings are in milliseconds
+	Value float64 `json:"value"`
+	// change from the previous run in percent, positive if the metric went up
+	Change float64 `json:"change"`
+	// set if the metric got worse than the previous run by more than the tolerance
+	Regression bool `json:"regression"`
+}
+
+type TrendSeries struct {
+	Metric string       `json:"metric"`
+	Label  string       `json:"-"`
+	Points []TrendPoint `json:"points"`
+}
+
+// Latest returns the most recent point of the series
+func (s TrendSeries) Latest() TrendPoint {
+	return s.Points[len(s.Points)-1]
+}
+
+// parseTrendMetric parses the metrics saved by `gen-ux`, e.g. `92.50` or `1234.56ms`. timings are
+// returned in milliseconds
+func parseTrendMetric(value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number, true
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return float64(duration) / float64(time.Millisecond), true
+}
+
+// ListTrendEntries returns the lighthouse runs of the URL from history, the oldest one first. only the
+// most recent `limit` runs are returned if limit is positive
+func ListTrendEntries(url string, limit int) ([]HistoryEntry, error) {
+	index, err := ListHistoryEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+
+	// the index is sorted by the most recent run first
+	for _, indexEntry := range index {
+		if indexEntry.Tool != "lighthouse" || normalizeHistoryUrl(indexEntry.Url) != normalizeHistoryUrl(url) {
+			continue
+		}
+
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+
+		entry, _, err := readHistoryEntry(indexEntry.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	return entries, nil
+}
+
+// BuildTrends builds a series for each of TrendMetrics out of the entries, which are expected to be
+// sorted by the oldest one first. runs which don't have the metric are skipped, and so are metrics
+// which none of the runs have
+func BuildTrends(entries []HistoryEntry, tolerance float64) []TrendSeries {
+	var trends []TrendSeries
+
+	for _, metric := range TrendMetrics {
+		series := TrendSeries{Metric: metric.Key, Label: metric.Label}
+
+		for _, entry := range entries {
+			value, ok := parseTrendMetric(entry.Metrics[metric.Key])
+
+			if !ok {
+				continue
+			}
+
+			point := TrendPoint{Id: entry.Id, CreatedAt: entry.CreatedAt, Value: value}
+
+			if len(series.Points) != 0 {
+				previous := series.Latest().Value
+				point.Change = percentChange(previous, value)
+
+				worsened := point.Change
+				if metric.HigherIsBetter {
+					worsened = -worsened
+				}
+
+				point.Regression = worsened > tolerance
+			}
+
+			series.Points = append(series.Points, point)
+		}
+
+		if len(series.Points) != 0 {
+			trends = append(trends, series)
+		}
+	}
+
+	return trends
+}
+
+func percentChange(from float64, to float64) float64 {
+	if from == 0 {
+		if to == 0 {
+			return 0
+		}
+
+		return math.Copysign(100, to)
+	}
+
+	return (to - from) / math.Abs(from) * 100
+}
+
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders each of the values as a block whose height is relative to the lowest and highest of
+// the values, e.g. `▁▃▅█▆`
+func Sparkline(values []float64) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+
+	blocks := make([]string, len(values))
+
+	for i, value := range values {
+		level := len(sparklineBlocks) / 2
+		if high != low {
+			level = int(math.Round((value - low) / (high - low) * float64(len(sparklineBlocks)-1)))
+		}
+
+		blocks[i] = string(sparklineBlocks[level])
+	}
+
+	return blocks
+}
@@ -0,0 +1,306 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// issues which are accepted during triage are written to the suppression file, which lives next to the
+// project config so that it can be checked into the repository along with it
+const SuppressionFileName = ".insightly-suppressions.yaml"
+
+var (
+	TriageFix           = "fix"
+	TriageAccepted      = "accepted"
+	TriageFalsePositive = "false-positive"
+)
+
+// Suppression is an issue which was accepted, so it is left out of the later runs. suppressions without
+// a url apply to every page
+type Suppression struct {
+	Fingerprint string    `yaml:"fingerprint"`
+	Tool        string    `yaml:"tool"`
+	Rule        string    `yaml:"rule"`
+	Selector    string    `yaml:"selector,omitempty"`
+	Url         string    `yaml:"url,omitempty"`
+	Reason      string    `yaml:"reason"`
+	AcceptedAt  time.Time `yaml:"accepted_at"`
+}
+
+type SuppressionFile struct {
+	Suppressions []Suppression `yaml:"suppressions"`
+}
+
+// TriageDecision is what was decided for an issue of a page during triage. issues marked as false
+// positives are left out of the later runs, whereas the decision and the owner of the other issues are
+// carried over to them
+type TriageDecision struct {
+	Url         string `json:"url"`
+	Fingerprint string `json:"fingerprint"`
+	Tool        string `json:"tool"`
+	Rule        string `json:"rule"`
+	Selector    string `json:"selector,omitempty"`
+	// fix or false-positive, empty if only an owner was assigned
+	Decision string `json:"decision,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	// id of the run which the issue was triaged in
+	RunId     string    `json:"run_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Triage holds the triage decisions along with the suppressions of the project
+type Triage struct {
+	Decisions       []TriageDecision
+	Suppressions    []Suppression
+	SuppressionPath string
+}
+
+func GetTriageFilePath() string {
+	return filepath.Join(GetDataDirPath(), "triage.json")
+}
+
+// FindSuppressionFile walks up from the current working directory and returns the path of the first
+// suppression file which it finds. if there is none, the path where it should be created is returned,
+// i.e. next to the project config or in the current working directory
+func FindSuppressionFile() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for dir := cwd; ; {
+		path := filepath.Join(dir, SuppressionFileName)
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	_, projectConfigPath, err := FindProjectConfig()
+	if err != nil {
+		return "", err
+	}
+
+	if projectConfigPath != "" {
+		return filepath.Join(filepath.Dir(projectConfigPath), SuppressionFileName), nil
+	}
+
+	return filepath.Join(cwd, SuppressionFileName), nil
+}
+
+// LoadTriage reads the triage decisions and the suppression file, neither of which have to exist
+func LoadTriage() (Triage, error) {
+	var triage Triage
+
+	bytes, err := os.ReadFile(GetTriageFilePath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Triage{}, err
+	}
+
+	if len(bytes) != 0 {
+		if err := json.Unmarshal(bytes, &triage.Decisions); err != nil {
+			return Triage{}, fmt.Errorf("%s is corrupted: %w", GetTriageFilePath(), err)
+		}
+	}
+
+	triage.SuppressionPath, err = FindSuppressionFile()
+	if err != nil {
+		return Triage{}, err
+	}
+
+	bytes, err = os.ReadFile(triage.SuppressionPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Triage{}, err
+	}
+
+	var suppressionFile SuppressionFile
+
+	if err := yaml.Unmarshal(bytes, &suppressionFile); err != nil {
+		return Triage{}, fmt.Errorf("invalid suppression file %s: %w", triage.SuppressionPath, err)
+	}
+
+	triage.Suppressions = suppressionFile.Suppressions
+
+	return triage, nil
+}
+
+// Suppression returns the suppression which matches the issue of the page, or nil if it isn't suppressed
+func (t Triage) Suppression(url string, issue Issue) *Suppression {
+	fingerprint := IssueFingerprint(issue)
+
+	for i := range t.Suppressions {
+		if t.Suppressions[i].Fingerprint != fingerprint {
+			continue
+		}
+
+		if t.Suppressions[i].Url == "" || normalizeHistoryUrl(t.Suppressions[i].Url) == normalizeHistoryUrl(url) {
+			return &t.Suppressions[i]
+		}
+	}
+
+	return nil
+}
+
+// Decision returns the triage decision for the issue of the page, or nil if it wasn't triaged
+func (t Triage) Decision(url string, issue Issue) *TriageDecision {
+	i := t.decisionIndex(url, IssueFingerprint(issue))
+	if i == -1 {
+		return nil
+	}
+
+	return &t.Decisions[i]
+}
+
+func (t Triage) decisionIndex(url string, fingerprint string) int {
+	for i := range t.Decisions {
+		if t.Decisions[i].Fingerprint == fingerprint && normalizeHistoryUrl(t.Decisions[i].Url) == normalizeHistoryUrl(url) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Skips reports whether the issue of the page should be left out of the run, i.e. it was accepted or
+// marked as a false positive
+func (t Triage) Skips(url string, issue Issue) bool {
+	if t.Suppression(url, issue) != nil {
+		return true
+	}
+
+	decision := t.Decision(url, issue)
+
+	return decision != nil && decision.Decision == TriageFalsePositive
+}
+
+// Annotate sets the triage decision and the owner of each of the issues of the page
+func (t Triage) Annotate(url string, issues []Issue) {
+	for i := range issues {
+		issues[i].Triage = t.Status(url, issues[i])
+		issues[i].Owner = ""
+
+		if decision := t.Decision(url, issues[i]); decision != nil {
+			issues[i].Owner = decision.Owner
+		}
+	}
+}
+
+// Status returns the triage decision for the issue of the page, taking the suppressions into account
+func (t Triage) Status(url string, issue Issue) string {
+	if t.Suppression(url, issue) != nil {
+		return TriageAccepted
+	}
+
+	if decision := t.Decision(url, issue); decision != nil {
+		return decision.Decision
+	}
+
+	return ""
+}
+
+// SetDecision records the decision and the owner for the issue of the page, removing the record if both
+// of them are empty
+func (t *Triage) SetDecision(runId string, url string, issue Issue, decision string, owner string) error {
+	fingerprint := IssueFingerprint(issue)
+	i := t.decisionIndex(url, fingerprint)
+
+	switch {
+	case decision == "" && owner == "":
+		if i != -1 {
+			t.Decisions = append(t.Decisions[:i], t.Decisions[i+1:]...)
+		}
+	case i == -1:
+		t.Decisions = append(t.Decisions, TriageDecision{
+			Url:         url,
+			Fingerprint: fingerprint,
+			Tool:        issue.Tool,
+			Rule:        issue.Rule,
+			Selector:    issue.Selector,
+			Decision:    decision,
+			Owner:       owner,
+			RunId:       runId,
+			UpdatedAt:   time.Now(),
+		})
+	default:
+		t.Decisions[i].Decision = decision
+		t.Decisions[i].Owner = owner
+		t.Decisions[i].RunId = runId
+		t.Decisions[i].UpdatedAt = time.Now()
+	}
+
+	if err := ensureDir(GetDataDirPath()); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(&t.Decisions, "", " ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(GetTriageFilePath(), bytes, 0600)
+}
+
+// Accept adds the issue of the page to the suppression file along with the reason
+func (t *Triage) Accept(url string, issue Issue, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("a reason is required for accepting an issue")
+	}
+
+	if t.Suppression(url, issue) != nil {
+		return nil
+	}
+
+	t.Suppressions = append(t.Suppressions, Suppression{
+		Fingerprint: IssueFingerprint(issue),
+		Tool:        issue.Tool,
+		Rule:        issue.Rule,
+		Selector:    issue.Selector,
+		Url:         url,
+		Reason:      strings.TrimSpace(reason),
+		AcceptedAt:  time.Now().UTC().Truncate(time.Second),
+	})
+
+	return t.writeSuppressions()
+}
+
+// Unaccept removes the suppression which matches the issue of the page, if any
+func (t *Triage) Unaccept(url string, issue Issue) error {
+	suppression := t.Suppression(url, issue)
+	if suppression == nil {
+		return nil
+	}
+
+	for i := range t.Suppressions {
+		if &t.Suppressions[i] == suppression {
+			t.Suppressions = append(t.Suppressions[:i], t.Suppressions[i+1:]...)
+			break
+		}
+	}
+
+	return t.writeSuppressions()
+}
+
+func (t Triage) writeSuppressions() error {
+	bytes, err := yaml.Marshal(&SuppressionFile{Suppressions: t.Suppressions})
+	if err != nil {
+		return err
+	}
+
+	header := "# issues accepted via `insightly triage`, which are left out of the later runs\n"
+
+	return os.WriteFile(t.SuppressionPath, append([]byte(header), bytes...), 0644)
+}
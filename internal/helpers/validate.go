@@ -0,0 +1,242 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type KeyStatus string
+
+var (
+	KeyValid       KeyStatus = "valid"
+	KeyInvalid     KeyStatus = "invalid"
+	KeyExpired     KeyStatus = "expired"
+	KeyRateLimited KeyStatus = "rate-limited"
+	// the provider couldn't be reached or responded with an unexpected error, so the key might be valid
+	KeyUnavailable KeyStatus = "unavailable"
+	// there is no way of validating the key of the LLM yet
+	KeyUnchecked KeyStatus = "unchecked"
+)
+
+type KeyCheck struct {
+	Llm    Llm
+	Status KeyStatus
+	// where the key came from, see ResolveLlmKeyWithSource
+	Source  string
+	Message string
+}
+
+// keyValidationEndpoints are cheap endpoints which only succeed with a valid key. hugging face keys are
+// used for both mistral and qwen
+var keyValidationEndpoints = map[Llm]string{
+	Gemini:  "https://generativelanguage.googleapis.com/v1beta/models",
+	Mistral: "https://huggingface.co/api/whoami-v2",
+	Qwen:    "https://huggingface.co/api/whoami-v2",
+	Chatgpt: "https://api.openai.com/v1/models",
+	Claude:  "https://api.anthropic.com/v1/models",
+}
+
+var keyValidationClient = http.Client{Timeout: 15 * time.Second}
+
+// GetKeyValidationUrlEnvVar returns the environment variable which overrides the endpoint used for
+// validating the API key of the LLM, e.g. `INSIGHTLY_GEMINI_VALIDATE_URL`. it is meant for pointing
+// insightly at a proxy or a local stub
+func GetKeyValidationUrlEnvVar(llm Llm) string {
+	return fmt.Sprintf("INSIGHTLY_%s_VALIDATE_URL", strings.ToUpper(string(llm)))
+}
+
+func getKeyValidationUrl(llm Llm) string {
+	if url := os.Getenv(GetKeyValidationUrlEnvVar(llm)); url != "" {
+		return url
+	}
+
+	return keyValidationEndpoints[llm]
+}
+
+// CheckApiKey validates the API key against the LLM's provider
+func CheckApiKey(llm Llm, key string) KeyCheck {
+	check := KeyCheck{Llm: llm}
+
+	url := getKeyValidationUrl(llm)
+	if url == "" {
+		check.Status = KeyUnchecked
+		check.Message = fmt.Sprintf("API keys of %s can't be validated yet", llm)
+		return check
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		check.Status = KeyUnavailable
+		check.Message = err.Error()
+		return check
+	}
+
+	switch llm {
+	case Gemini:
+		req.Header.Set("x-goog-api-key", key)
+	case Claude:
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	default:
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := keyValidationClient.Do(req)
+	if err != nil {
+		check.Status = KeyUnavailable
+
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			check.Message = "timed out"
+		} else {
+			check.Message = err.Error()
+		}
+
+		return check
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	check.Status = keyStatusFromResponse(resp.StatusCode, body)
+
+	if check.Status != KeyValid {
+		check.Message = fmt.Sprintf("responded with status code %d: %s", resp.StatusCode, errorMessageFromBody(body))
+	}
+
+	return check
+}
+
+func keyStatusFromResponse(statusCode int, body []byte) KeyStatus {
+	message := strings.ToLower(string(body))
+
+	// only the responses which reject the key are checked for expiry, e.g. a rate limited response might
+	// mention that the quota window expired
+	rejected := statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden ||
+		// gemini responds with 400 instead of 401 for invalid and expired keys
+		(statusCode == http.StatusBadRequest && (strings.Contains(message, "api_key_invalid") || strings.Contains(message, "api key not valid") || strings.Contains(message, "expired")))
+
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return KeyValid
+	case statusCode == http.StatusTooManyRequests:
+		return KeyRateLimited
+	case rejected && strings.Contains(message, "expired"):
+		return KeyExpired
+	case rejected:
+		return KeyInvalid
+	default:
+		return KeyUnavailable
+	}
+}
+
+// errorMessageFromBody picks the error message out of the usual `{"error": {"message": ...}}` or
+// `{"error": ...}` responses, falling back to the whole body
+func errorMessageFromBody(body []byte) string {
+	var data struct {
+		Error json.RawMessage `json:"error"`
+	}
+
+	if err := json.Unmarshal(body, &data); err == nil && len(data.Error) != 0 {
+		var nested struct {
+			Message string `json:"message"`
+		}
+
+		if err := json.Unmarshal(data.Error, &nested); err == nil && nested.Message != "" {
+			return nested.Message
+		}
+
+		var message string
+		if err := json.Unmarshal(data.Error, &message); err == nil && message != "" {
+			return message
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+
+	return message
+}
+
+// CheckLlmKey validates the key which would be used for the LLM, i.e. the one resolved by
+// ResolveLlmKeyWithSource
+func CheckLlmKey(llmConfig LlmConfig) KeyCheck {
+	key, source, err := ResolveLlmKeyWithSource(llmConfig)
+	if err != nil {
+		return KeyCheck{Llm: llmConfig.Name, Status: KeyInvalid, Message: err.Error()}
+	}
+
+	check := CheckApiKey(llmConfig.Name, key)
+	check.Source = source
+
+	return check
+}
+
+// CheckApiKeyCmd validates the key printed by the command against the LLM's provider. unlike CheckLlmKey,
+// the command is run even if the key is set via the environment
+func CheckApiKeyCmd(llm Llm, command string) KeyCheck {
+	key, err := runApiKeyCmd(command)
+	if err != nil {
+		return KeyCheck{Llm: llm, Status: KeyInvalid, Message: fmt.Sprintf("failed to run api_key_cmd: %s", err.Error())}
+	}
+
+	check := CheckApiKey(llm, key)
+	check.Source = fmt.Sprintf("command `%s`", command)
+
+	return check
+}
+
+// CheckLlmKeysConcurrently validates the keys of the LLMs at the same time, returning the checks in the
+// same order as the given LLMs
+func CheckLlmKeysConcurrently(llmConfigs []LlmConfig) []KeyCheck {
+	checks := make([]KeyCheck, len(llmConfigs))
+	keys := make([]string, len(llmConfigs))
+
+	// keys are resolved one by one, as resolving them might prompt for the vault passphrase
+	for i := range llmConfigs {
+		key, source, err := ResolveLlmKeyWithSource(llmConfigs[i])
+		if err != nil {
+			checks[i] = KeyCheck{Llm: llmConfigs[i].Name, Status: KeyInvalid, Message: err.Error()}
+			continue
+		}
+
+		keys[i] = key
+		checks[i].Source = source
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range llmConfigs {
+		if keys[i] == "" {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			source := checks[i].Source
+			checks[i] = CheckApiKey(llmConfigs[i].Name, keys[i])
+			checks[i].Source = source
+		}(i)
+	}
+
+	wg.Wait()
+
+	return checks
+}
+
+// IsKeyRejected reports whether the provider rejected the key, as opposed to not being able to tell
+func IsKeyRejected(check KeyCheck) bool {
+	return check.Status == KeyInvalid || check.Status == KeyExpired
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import "testing"
+
+func TestKeyStatusFromResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		expected   KeyStatus
+	}{
+		{"valid", 200, `{"models": []}`, KeyValid},
+		{"invalid", 401, `{"error": {"message": "Invalid API key"}}`, KeyInvalid},
+		{"expired", 401, `{"error": {"message": "API key expired"}}`, KeyExpired},
+		{"gemini invalid", 400, `{"error": {"message": "API key not valid. Please pass a valid API key.", "status": "INVALID_ARGUMENT"}}`, KeyInvalid},
+		{"gemini expired", 400, `{"error": {"message": "API key expired. Please renew the API key.", "status": "INVALID_ARGUMENT"}}`, KeyExpired},
+		{"rate limited", 429, `{"error": {"message": "Too many requests"}}`, KeyRateLimited},
+		{"rate limited mentioning expired", 429, `{"error": {"message": "quota window expired, retry later"}}`, KeyRateLimited},
+		{"unavailable mentioning expired", 503, `{"error": {"message": "upstream certificate expired"}}`, KeyUnavailable},
+		{"bad request", 400, `{"error": {"message": "malformed request"}}`, KeyUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if status := keyStatusFromResponse(test.statusCode, []byte(test.body)); status != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package helpers
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WcagCriteria are the names of the WCAG 2.2 success criteria
+var WcagCriteria = map[string]string{
+	"1.1.1":  "Non-text Content",
+	"1.2.1":  "Audio-only and Video-only (Prerecorded)",
+	"1.2.2":  "Captions (Prerecorded)",
+	"1.2.3":  "Audio Description or Media Alternative (Prerecorded)",
+	"1.2.4":  "Captions (Live)",
+	"1.2.5":  "Audio Description (Prerecorded)",
+	"1.2.6":  "Sign Language (Prerecorded)",
+	"1.2.7":  "Extended Audio Description (Prerecorded)",
+	"1.2.8":  "Media Alternative (Prerecorded)",
+	"1.2.9":  "Audio-only (Live)",
+	"1.3.1":  "Info and Relationships",
+	"1.3.2":  "Meaningful Sequence",
+	"1.3.3":  "Sensory Characteristics",
+	"1.3.4":  "Orientation",
+	"1.3.5":  "Identify Input Purpose",
+	"1.3.6":  "Identify Purpose",
+	"1.4.1":  "Use of Color",
+	"1.4.2":  "Audio Control",
+	"1.4.3":  "Contrast (Minimum)",
+	"1.4.4":  "Resize Text",
+	"1.4.5":  "Images of Text",
+	"1.4.6":  "Contrast (Enhanced)",
+	"1.4.7":  "Low or No Background Audio",
+	"1.4.8":  "Visual Presentation",
+	"1.4.9":  "Images of Text (No Exception)",
+	"1.4.10": "Reflow",
+	"1.4.11": "Non-text Contrast",
+	"1.4.12": "Text Spacing",
+	"1.4.13": "Content on Hover or Focus",
+	"2.1.1":  "Keyboard",
+	"2.1.2":  "No Keyboard Trap",
+	"2.1.3":  "Keyboard (No Exception)",
+	"2.1.4":  "Character Key Shortcuts",
+	"2.2.1":  "Timing Adjustable",
+	"2.2.2":  "Pause, Stop, Hide",
+	"2.2.3":  "No Timing",
+	"2.2.4":  "Interruptions",
+	"2.2.5":  "Re-authenticating",
+	"2.2.6":  "Timeouts",
+	"2.3.1":  "Three Flashes or Below Threshold",
+	"2.3.2":  "Three Flashes",
+	"2.3.3":  "Animation from Interactions",
+	"2.4.1":  "Bypass Blocks",
+	"2.4.2":  "Page Titled",
+	"2.4.3":  "Focus Order",
+	"2.4.4":  "Link Purpose (In Context)",
+	"2.4.5":  "Multiple Ways",
+	"2.4.6":  "Headings and Labels",
+	"2.4.7":  "Focus Visible",
+	"2.4.8":  "Location",
+	"2.4.9":  "Link Purpose (Link Only)",
+	"2.4.10": "Section Headings",
+	"2.4.11": "Focus Not Obscured (Minimum)",
+	"2.4.12": "Focus Not Obscured (Enhanced)",
+	"2.4.13": "Focus Appearance",
+	"2.5.1":  "Pointer Gestures",
+	"2.5.2":  "Pointer Cancellation",
+	"2.5.3":  "Label in Name",
+	"2.5.4":  "Motion Actuation",
+	"2.5.5":  "Target Size (Enhanced)",
+	"2.5.6":  "Concurrent Input Mechanisms",
+	"2.5.7":  "Dragging Movements",
+	"2.5.8":  "Target Size (Minimum)",
+	"3.1.1":  "Language of Page",
+	"3.1.2":  "Language of Parts",
+	"3.1.3":  "Unusual Words",
+	"3.1.4":  "Abbreviations",
+	"3.1.5":  "Reading Level",
+	"3.1.6":  "Pronunciation",
+	"3.2.1":  "On Focus",
+	"3.2.2":  "On Input",
+	"3.2.3":  "Consistent Navigation",
+	"3.2.4":  "Consistent Identification",
+	"3.2.5":  "Change on Request",
+	"3.2.6":  "Consistent Help",
+	"3.3.1":  "Error Identification",
+	"3.3.2":  "Labels or Instructions",
+	"3.3.3":  "Error Suggestion",
+	"3.3.4":  "Error Prevention (Legal, Financial, Data)",
+	"3.3.5":  "Help",
+	"3.3.6":  "Error Prevention (All)",
+	"3.3.7":  "Redundant Entry",
+	"3.3.8":  "Accessible Authentication (Minimum)",
+	"3.3.9":  "Accessible Authentication (Enhanced)",
+	"4.1.1":  "Parsing",
+	"4.1.2":  "Name, Role, Value",
+	"4.1.3":  "Status Messages",
+}
+
+// criteria of the lighthouse accessibility audits, which are axe-core rules. audits which aren't
+// listed (e.g. the performance ones or axe's best practices) don't map to a criterion
+var lighthouseWcagCriteria = map[string]string{
+	"image-alt":                    "1.1.1",
+	"input-image-alt":              "1.1.1",
+	"object-alt":                   "1.1.1",
+	"role-img-alt":                 "1.1.1",
+	"svg-img-alt":                  "1.1.1",
+	"video-caption":                "1.2.2",
+	"definition-list":              "1.3.1",
+	"dlitem":                       "1.3.1",
+	"list":                         "1.3.1",
+	"listitem":                     "1.3.1",
+	"td-headers-attr":              "1.3.1",
+	"th-has-data-cells":            "1.3.1",
+	"aria-required-children":       "1.3.1",
+	"aria-required-parent":         "1.3.1",
+	"autocomplete-valid":           "1.3.5",
+	"link-in-text-block":           "1.4.1",
+	"color-contrast":               "1.4.3",
+	"meta-viewport":                "1.4.4",
+	"meta-refresh":                 "2.2.1",
+	"bypass":                       "2.4.1",
+	"document-title":               "2.4.2",
+	"link-name":                    "2.4.4",
+	"identical-links-same-purpose": "2.4.9",
+	"target-size":                  "2.5.8",
+	"html-has-lang":                "3.1.1",
+	"html-lang-valid":              "3.1.1",
+	"valid-lang":                   "3.1.2",
+	"form-field-multiple-labels":   "3.3.2",
+	"duplicate-id-aria":            "4.1.1",
+	"aria-allowed-attr":            "4.1.2",
+	"aria-command-name":            "4.1.2",
+	"aria-hidden-body":             "4.1.2",
+	"aria-hidden-focus":            "4.1.2",
+	"aria-input-field-name":        "4.1.2",
+	"aria-meter-name":              "4.1.2",
+	"aria-progressbar-name":        "4.1.2",
+	"aria-required-attr":           "4.1.2",
+	"aria-roles":                   "4.1.2",
+	"aria-toggle-field-name":       "4.1.2",
+	"aria-tooltip-name":            "4.1.2",
+	"aria-valid-attr":              "4.1.2",
+	"aria-valid-attr-value":        "4.1.2",
+	"button-name":                  "4.1.2",
+	"frame-title":                  "4.1.2",
+	"input-button-name":            "4.1.2",
+	"label":                        "4.1.2",
+	"select-name":                  "4.1.2",
+	"aria-deprecated-role":         "4.1.2",
+	"aria-prohibited-attr":         "4.1.2",
+	"aria-conditional-attr":        "4.1.2",
+	"aria-text":                    "4.1.2",
+	"aria-treeitem-name":           "4.1.2",
+}
+
+// pa11y codes look like `WCAG2AA.Principle1.Guideline1_4.1_4_3.G18.Fail`
+var pa11yWcagCriterionRegex = regexp.MustCompile(`Guideline\d+_\d+\.(\d+_\d+_\d+)`)
+
+// WcagCriterion returns the WCAG success criterion which the issue fails, e.g. `1.4.3`, or an empty string
+// if it doesn't map to one
+func WcagCriterion(issue Issue) string {
+	switch issue.Tool {
+	case "pa11y":
+		if matches := pa11yWcagCriterionRegex.FindStringSubmatch(issue.Rule); matches != nil {
+			return strings.ReplaceAll(matches[1], "_", ".")
+		}
+	case "lighthouse":
+		return lighthouseWcagCriteria[issue.Rule]
+	}
+
+	return ""
+}
+
+// SortWcagCriteria sorts the criteria in the order of the guidelines, i.e. `1.4.3` before `1.4.10`. empty
+// criteria are sorted last
+func SortWcagCriteria(criteria []string) {
+	sort.SliceStable(criteria, func(i, j int) bool {
+		a, b := strings.Split(criteria[i], "."), strings.Split(criteria[j], ".")
+
+		if criteria[i] == "" || criteria[j] == "" {
+			return criteria[j] == "" && criteria[i] != ""
+		}
+
+		for k := 0; k < len(a) && k < len(b); k++ {
+			x, _ := strconv.Atoi(a[k])
+			y, _ := strconv.Atoi(b[k])
+
+			if x != y {
+				return x < y
+			}
+		}
+
+		return len(a) < len(b)
+	})
+}
@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AskFunc sends the conversation so far to the LLM and returns its answer to the last question
+type AskFunc func(turns []helpers.ChatTurn) (string, error)
+
+// SaveFunc persists the conversation after every answer
+type SaveFunc func(turns []helpers.ChatTurn) error
+
+type answerMsg struct {
+	answer string
+	err    error
+}
+
+type ChatModel struct {
+	title    string
+	turns    []helpers.ChatTurn
+	ask      AskFunc
+	save     SaveFunc
+	viewport viewport.Model
+	input    textinput.Model
+	spinner  spinner.Model
+	waiting  bool
+	ready    bool
+	err      error
+	// error of the last attempt to save the transcript, and whether any of the attempts succeeded
+	saveErr error
+	saved   bool
+}
+
+var chatHelpStyle = lipgloss.NewStyle().Faint(true)
+
+// the default viewport keymap uses letters for scrolling, which would conflict with typing in the input
+var chatViewportKeyMap = viewport.KeyMap{
+	Up:           key.NewBinding(key.WithKeys("up")),
+	Down:         key.NewBinding(key.WithKeys("down")),
+	PageUp:       key.NewBinding(key.WithKeys("pgup")),
+	PageDown:     key.NewBinding(key.WithKeys("pgdown")),
+	HalfPageUp:   key.NewBinding(key.WithDisabled()),
+	HalfPageDown: key.NewBinding(key.WithDisabled()),
+}
+
+func NewChatModel(title string, turns []helpers.ChatTurn, ask AskFunc, save SaveFunc) ChatModel {
+	input := textinput.New()
+	input.Placeholder = "ask a question about the report (esc to quit)"
+	input.Prompt = "> "
+	input.Focus()
+
+	return ChatModel{
+		title:   title,
+		turns:   turns,
+		ask:     ask,
+		save:    save,
+		input:   input,
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+	}
+}
+
+// SaveErr returns the error of the last attempt to save the transcript, which is nil if the transcript
+// was saved again after an earlier attempt failed
+func (m ChatModel) SaveErr() error {
+	return m.saveErr
+}
+
+// Saved reports whether the transcript was written at least once
+func (m ChatModel) Saved() bool {
+	return m.saved
+}
+
+func (m ChatModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.spinner.Tick)
+}
+
+func (m ChatModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// title, input and status line take up three lines
+		height := msg.Height - 3
+		if height < 1 {
+			height = 1
+		}
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, height)
+			m.viewport.KeyMap = chatViewportKeyMap
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = height
+		}
+
+		m.input.Width = msg.Width - 4
+		m.refreshViewport()
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			question := strings.TrimSpace(m.input.Value())
+			if question == "" || m.waiting {
+				return m, nil
+			}
+
+			if question == "/exit" || question == "/quit" {
+				return m, tea.Quit
+			}
+
+			m.input.Reset()
+			m.turns = append(m.turns, helpers.ChatTurn{Role: "user", Content: question, CreatedAt: time.Now()})
+			m.waiting = true
+			m.err = nil
+			m.refreshViewport()
+
+			turns := append([]helpers.ChatTurn{}, m.turns...)
+			ask := m.ask
+
+			return m, func() tea.Msg {
+				answer, err := ask(turns)
+				return answerMsg{answer: answer, err: err}
+			}
+		}
+	case answerMsg:
+		m.waiting = false
+
+		if msg.err != nil {
+			m.err = msg.err
+			// drop the unanswered question, so that it can be asked again
+			m.input.SetValue(m.turns[len(m.turns)-1].Content)
+			m.turns = m.turns[:len(m.turns)-1]
+		} else {
+			m.turns = append(m.turns, helpers.ChatTurn{Role: "assistant", Content: strings.TrimSpace(msg.answer), CreatedAt: time.Now()})
+
+			m.saveErr = m.save(m.turns)
+			if m.saveErr != nil {
+				m.err = m.saveErr
+			} else {
+				m.saved = true
+			}
+		}
+
+		m.refreshViewport()
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	var cmd tea.Cmd
+
+	m.input, cmd = m.input.Update(msg)
+	cmds = append(cmds, cmd)
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m *ChatModel) refreshViewport() {
+	if !m.ready {
+		return
+	}
+
+	textStyle := lipgloss.NewStyle().Width(m.viewport.Width)
+
+	var content strings.Builder
+
+	for i := range m.turns {
+		if m.turns[i].Role == "user" {
+			content.WriteString(styles.BoldBlueTextStyle.Render("you") + "\n")
+		} else {
+			content.WriteString(styles.BoldPinkTextStyle.Render("insightly") + "\n")
+		}
+
+		content.WriteString(textStyle.Render(m.turns[i].Content) + "\n\n")
+	}
+
+	m.viewport.SetContent(content.String())
+	m.viewport.GotoBottom()
+}
+
+func (m ChatModel) View() string {
+	if !m.ready {
+		return "loading..."
+	}
+
+	status := chatHelpStyle.Render("enter: send • ↑/↓: scroll • esc: quit")
+
+	if m.waiting {
+		status = fmt.Sprintf("%s thinking...", m.spinner.View())
+	} else if m.err != nil {
+		status = styles.BoldPinkTextStyle.Render(m.err.Error())
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", styles.BoldBlueTextStyle.Render(m.title), m.viewport.View(), m.input.View(), status)
+}
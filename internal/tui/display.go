@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// environment variable which picks how documents are displayed, one of tui, pager, editor or stdout
+const ViewerEnvVar = "INSIGHTLY_VIEWER"
+
+var ValidViewers = []string{"tui", "pager", "editor", "stdout"}
+
+// Display shows the document in the built-in viewer. it falls back to `$PAGER`, then to `$EDITOR` and
+// then to printing to stdout when the viewer can't be used, e.g. when stdout isn't a terminal, in which
+// case the document is printed right away
+func Display(doc Document) error {
+	return DisplayTo(doc, os.Stdout)
+}
+
+// DisplayTo is Display which shows the document on the given terminal, e.g. stderr when stdout is piped
+func DisplayTo(doc Document, output *os.File) error {
+	viewer := os.Getenv(ViewerEnvVar)
+
+	if viewer != "" && !isValidViewer(viewer) {
+		return fmt.Errorf("invalid %s %s, valid viewers are %s", ViewerEnvVar, viewer, strings.Join(ValidViewers, ", "))
+	}
+
+	if !term.IsTerminal(int(output.Fd())) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		viewer = "stdout"
+	}
+
+	switch viewer {
+	case "", "tui":
+		if err := displayInViewer(doc, output); err == nil {
+			return nil
+		}
+
+		fallthrough
+	case "pager":
+		if err := displayInPager(doc, output); err == nil || !errors.Is(err, exec.ErrNotFound) {
+			return err
+		}
+
+		fallthrough
+	case "editor":
+		if err := displayInEditor(doc, output); err == nil || !errors.Is(err, exec.ErrNotFound) {
+			return err
+		}
+	}
+
+	fmt.Fprint(output, doc.Text())
+
+	return nil
+}
+
+func isValidViewer(viewer string) bool {
+	for _, valid := range ValidViewers {
+		if viewer == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func displayInViewer(doc Document, output *os.File) error {
+	_, err := tea.NewProgram(NewViewerModel(doc, MarkdownStyle()), tea.WithAltScreen(), tea.WithOutput(output)).Run()
+
+	return err
+}
+
+// MarkdownStyle returns the glamour style which suits the background of the terminal. the background has
+// to be detected before the program takes over the terminal
+func MarkdownStyle() string {
+	if !lipgloss.HasDarkBackground() {
+		return "light"
+	}
+
+	return "dark"
+}
+
+// commandFromEnv splits the command set via the environment variable, e.g. `less -R`. exec.ErrNotFound
+// is returned if it isn't set
+func commandFromEnv(envVar string) ([]string, error) {
+	command := strings.Fields(os.Getenv(envVar))
+	if len(command) == 0 {
+		return nil, fmt.Errorf("%s isn't set: %w", envVar, exec.ErrNotFound)
+	}
+
+	return command, nil
+}
+
+func displayInPager(doc Document, output *os.File) error {
+	command, err := commandFromEnv("PAGER")
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewBufferString(doc.Text())
+	cmd.Stdout = output
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func displayInEditor(doc Document, output *os.File) error {
+	command, err := commandFromEnv("VISUAL")
+	if err != nil {
+		command, err = commandFromEnv("EDITOR")
+		if err != nil {
+			return err
+		}
+	}
+
+	extension := "md"
+	if doc.Markdown == "" && doc.Raw != "" && doc.RawType != "" {
+		extension = doc.RawType
+	}
+
+	file, err := os.CreateTemp("", "insightly-*."+extension)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(doc.Text()); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(command[0], append(command[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = output
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// Text returns the document as plain text for the pager, the editor and stdout. the markdown is returned
+// as is, followed by the issues if there are any, whereas reports without markdown are returned raw so
+// that they can be piped into other tools
+func (doc Document) Text() string {
+	if doc.Markdown == "" && doc.Raw != "" {
+		return doc.Raw
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(strings.TrimSpace(doc.Markdown))
+	builder.WriteString("\n")
+
+	if len(doc.Issues) != 0 {
+		builder.WriteString("\n## Issues\n\n")
+
+		for _, group := range groupIssues(doc.Issues) {
+			fmt.Fprintf(&builder, "- **%s** `%s` (%d): %s\n", group.severity, group.rule, len(group.issues), group.message)
+
+			for _, issue := range group.issues {
+				if issue.Selector != "" {
+					fmt.Fprintf(&builder, "  - `%s`%s\n", issue.Selector, plainIssueStatus(issue))
+				}
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+func plainIssueStatus(issue helpers.Issue) string {
+	switch issue.Status {
+	case helpers.IssueNew:
+		return " (new)"
+	case helpers.IssuePersisting:
+		return fmt.Sprintf(" (open since %s)", issue.FirstSeen.Local().Format("2006-01-02"))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,477 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// TriageItem is an issue along with what was decided for it
+type TriageItem struct {
+	Issue helpers.Issue
+	// fix, accepted or false-positive, empty if the issue wasn't triaged yet
+	Decision string
+	Owner    string
+	// why the issue was accepted
+	Reason string
+}
+
+// SaveTriageFunc persists the decision for the item, previous is the item before it was changed
+type SaveTriageFunc func(item TriageItem, previous TriageItem) error
+
+// AskFixFunc asks the LLM how to fix the issue
+type AskFixFunc func(issue helpers.Issue) (string, error)
+
+type fixMsg struct {
+	item   int
+	answer string
+	err    error
+}
+
+// what the text input at the bottom is used for
+type triagePrompt string
+
+var (
+	noPrompt     triagePrompt = ""
+	reasonPrompt triagePrompt = "reason"
+	ownerPrompt  triagePrompt = "owner"
+)
+
+var triageDecisionStyles = map[string]lipgloss.Style{
+	helpers.TriageFix:           lipgloss.NewStyle().Foreground(lipgloss.Color("#addfff")),
+	helpers.TriageAccepted:      lipgloss.NewStyle().Faint(true),
+	helpers.TriageFalsePositive: lipgloss.NewStyle().Faint(true).Strikethrough(true),
+}
+
+type TriageModel struct {
+	title string
+	items []TriageItem
+	save  SaveTriageFunc
+	ask   AskFixFunc
+	style string
+
+	width  int
+	height int
+	ready  bool
+
+	cursor int
+	offset int
+
+	// details of the issue under the cursor, along with the fix suggested by the LLM
+	details  bool
+	viewport viewport.Model
+	fixes    map[int]string
+	asking   int
+	spinner  spinner.Model
+
+	prompt triagePrompt
+	input  textinput.Model
+	err    error
+}
+
+// NewTriageModel creates the triage view, ask is nil if no LLM is configured. style is the glamour style
+// used for rendering the suggested fixes
+func NewTriageModel(title string, items []TriageItem, save SaveTriageFunc, ask AskFixFunc, style string) TriageModel {
+	input := textinput.New()
+
+	return TriageModel{
+		title:   title,
+		items:   items,
+		save:    save,
+		ask:     ask,
+		style:   style,
+		fixes:   make(map[int]string),
+		asking:  -1,
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+		input:   input,
+	}
+}
+
+// Items returns the items along with the decisions made for them
+func (m TriageModel) Items() []TriageItem {
+	return m.items
+}
+
+func (m TriageModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m TriageModel) contentHeight() int {
+	// title, counts and status line take up three lines
+	return max(m.height-3, 1)
+}
+
+func (m TriageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, m.contentHeight())
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = m.contentHeight()
+		}
+
+		m.input.Width = msg.Width - 12
+		m.refresh()
+
+		return m, nil
+	case fixMsg:
+		m.asking = -1
+
+		if msg.err != nil {
+			m.err = msg.err
+		} else {
+			m.fixes[msg.item] = strings.TrimSpace(msg.answer)
+		}
+
+		m.refresh()
+
+		return m, nil
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if m.prompt != noPrompt {
+			return m.updatePrompt(msg)
+		}
+
+		return m.updateKeys(msg)
+	}
+
+	return m, nil
+}
+
+func (m TriageModel) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if len(m.items) == 0 {
+		if key := msg.String(); key == "q" || key == "esc" || key == "ctrl+c" {
+			return m, tea.Quit
+		}
+
+		return m, nil
+	}
+
+	item := m.items[m.cursor]
+	m.err = nil
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "esc":
+		if !m.details {
+			return m, tea.Quit
+		}
+
+		m.details = false
+	case "enter", " ":
+		m.details = !m.details
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, len(m.items)-1)
+	case "pgup":
+		// the details are scrolled instead, as the suggested fix might not fit on screen
+		if m.details {
+			m.viewport.ViewUp()
+			return m, nil
+		}
+
+		m.cursor = max(m.cursor-m.contentHeight(), 0)
+	case "pgdown":
+		if m.details {
+			m.viewport.ViewDown()
+			return m, nil
+		}
+
+		m.cursor = min(m.cursor+m.contentHeight(), len(m.items)-1)
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.items) - 1
+	case "f":
+		m.decide(toggleDecision(item, helpers.TriageFix))
+	case "p":
+		m.decide(toggleDecision(item, helpers.TriageFalsePositive))
+	case "a":
+		if item.Decision == helpers.TriageAccepted {
+			m.decide(toggleDecision(item, helpers.TriageAccepted))
+			break
+		}
+
+		return m, m.startPrompt(reasonPrompt, "")
+	case "o":
+		return m, m.startPrompt(ownerPrompt, item.Owner)
+	case "u":
+		item.Decision = ""
+		item.Reason = ""
+		item.Owner = ""
+		m.decide(item)
+	case "l":
+		return m, m.askFix()
+	}
+
+	m.refresh()
+
+	return m, nil
+}
+
+func toggleDecision(item TriageItem, decision string) TriageItem {
+	if item.Decision == decision {
+		item.Decision = ""
+	} else {
+		item.Decision = decision
+	}
+
+	item.Reason = ""
+
+	return item
+}
+
+// decide saves the changed item under the cursor, keeping the previous one if it can't be saved
+func (m *TriageModel) decide(item TriageItem) {
+	previous := m.items[m.cursor]
+
+	if err := m.save(item, previous); err != nil {
+		m.err = err
+		return
+	}
+
+	m.items[m.cursor] = item
+
+	// moving on to the next issue keeps triaging quick
+	if item.Decision != "" && item.Decision != previous.Decision && !m.details {
+		m.cursor = min(m.cursor+1, len(m.items)-1)
+	}
+}
+
+func (m *TriageModel) startPrompt(prompt triagePrompt, value string) tea.Cmd {
+	m.prompt = prompt
+	m.input.Prompt = fmt.Sprintf("%s: ", prompt)
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+
+	return m.input.Focus()
+}
+
+func (m TriageModel) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.prompt = noPrompt
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		item := m.items[m.cursor]
+
+		switch m.prompt {
+		case reasonPrompt:
+			// a reason is required for accepting an issue, so that the suppression file explains itself
+			if value == "" {
+				return m, nil
+			}
+
+			item.Decision = helpers.TriageAccepted
+			item.Reason = value
+		case ownerPrompt:
+			item.Owner = value
+		}
+
+		m.prompt = noPrompt
+		m.input.Blur()
+		m.decide(item)
+		m.refresh()
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return m, cmd
+}
+
+func (m *TriageModel) askFix() tea.Cmd {
+	if m.ask == nil {
+		m.err = fmt.Errorf("no LLM is configured, run `insightly setup` to set one up")
+		return nil
+	}
+
+	m.details = true
+
+	if m.asking != -1 {
+		return nil
+	}
+
+	if _, ok := m.fixes[m.cursor]; ok {
+		m.refresh()
+		return nil
+	}
+
+	m.asking = m.cursor
+	m.refresh()
+
+	item := m.cursor
+	issue := m.items[m.cursor].Issue
+	ask := m.ask
+
+	return func() tea.Msg {
+		answer, err := ask(issue)
+		return fixMsg{item: item, answer: answer, err: err}
+	}
+}
+
+// refresh keeps the cursor on screen and re-renders the details of the issue under it
+func (m *TriageModel) refresh() {
+	if !m.ready {
+		return
+	}
+
+	height := m.contentHeight()
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+
+	if m.details && len(m.items) != 0 {
+		m.viewport.SetContent(renderMarkdown(m.detailsMarkdown(), m.style, m.width))
+	}
+}
+
+func (m TriageModel) detailsMarkdown() string {
+	item := m.items[m.cursor]
+	issue := item.Issue
+
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "# %s\n\n", issue.Rule)
+	fmt.Fprintf(&builder, "**%s** reported by %s\n\n%s\n\n", issue.Severity, issue.Tool, issue.Message)
+
+	if issue.Selector != "" {
+		fmt.Fprintf(&builder, "Selector: `%s`\n\n", issue.Selector)
+	}
+
+	if issue.Context != "" {
+		fmt.Fprintf(&builder, "```html\n%s\n```\n\n", issue.Context)
+	}
+
+	if item.Decision != "" {
+		fmt.Fprintf(&builder, "Decision: **%s**", item.Decision)
+
+		if item.Reason != "" {
+			fmt.Fprintf(&builder, " (%s)", item.Reason)
+		}
+
+		builder.WriteString("\n\n")
+	}
+
+	if item.Owner != "" {
+		fmt.Fprintf(&builder, "Owner: **%s**\n\n", item.Owner)
+	}
+
+	if fix, ok := m.fixes[m.cursor]; ok {
+		fmt.Fprintf(&builder, "## Suggested fix\n\n%s\n", fix)
+	}
+
+	return builder.String()
+}
+
+func (m TriageModel) counts() string {
+	counts := map[string]int{}
+	for _, item := range m.items {
+		counts[item.Decision]++
+	}
+
+	return fmt.Sprintf("%d issues • %d to fix • %d accepted • %d false positives • %d untriaged", len(m.items), counts[helpers.TriageFix], counts[helpers.TriageAccepted], counts[helpers.TriageFalsePositive], counts[""])
+}
+
+func (m TriageModel) listView() string {
+	if len(m.items) == 0 {
+		return "no issues to triage"
+	}
+
+	var lines []string
+	end := min(m.offset+m.contentHeight(), len(m.items))
+
+	for i := m.offset; i < end; i++ {
+		item := m.items[i]
+		issue := item.Issue
+
+		decision := fmt.Sprintf("%-14s", item.Decision)
+		if item.Decision == "" {
+			decision = fmt.Sprintf("%-14s", "-")
+		}
+
+		detail := issue.Selector
+		if detail == "" {
+			detail = issue.Message
+		}
+
+		line := fmt.Sprintf("%s %s %s  %s", triageDecisionStyle(item.Decision).Render(decision), viewerSeverityStyles[issue.Severity].Render(fmt.Sprintf("%-7s", issue.Severity)), issue.Rule, detail)
+		if item.Owner != "" {
+			line += viewerHelpStyle.Render("  @" + item.Owner)
+		}
+
+		line = ansi.Truncate(line, m.width, "…")
+
+		if i == m.cursor {
+			line = viewerCursorStyle.Render(ansi.Strip(line))
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func triageDecisionStyle(decision string) lipgloss.Style {
+	if style, ok := triageDecisionStyles[decision]; ok {
+		return style
+	}
+
+	return viewerHelpStyle
+}
+
+func (m TriageModel) View() string {
+	if !m.ready {
+		return "loading..."
+	}
+
+	var content string
+
+	if m.details && len(m.items) != 0 {
+		content = m.viewport.View()
+	} else {
+		content = m.listView()
+		// the content is padded, so that the status line stays at the bottom
+		content += strings.Repeat("\n", max(m.contentHeight()-lipgloss.Height(content), 0))
+	}
+
+	status := viewerHelpStyle.Render(ansi.Truncate("↑/↓: move • enter: details • f: fix • a: accept • p: false positive • o: owner • u: undo • l: ask llm for a fix • q: quit", m.width, "…"))
+
+	switch {
+	case m.prompt != noPrompt:
+		status = m.input.View()
+	case m.asking != -1:
+		status = fmt.Sprintf("%s asking the LLM how to fix %s...", m.spinner.View(), m.items[m.asking].Issue.Rule)
+	case m.err != nil:
+		status = styles.BoldPinkTextStyle.Render(ansi.Truncate(m.err.Error(), m.width, "…"))
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", styles.BoldBlueTextStyle.Render(ansi.Truncate(m.title, m.width, "…")), viewerHelpStyle.Render(m.counts()), content, status)
+}
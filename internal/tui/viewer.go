@@ -0,0 +1,559 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xmukesh/insightly/internal/helpers"
+	"github.com/0xmukesh/insightly/internal/helpers/styles"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Document is what the viewer displays. each of the non-empty parts gets a tab of its own
+type Document struct {
+	Title string
+	// markdown, e.g. the AI summary, which is rendered in the summary tab
+	Markdown string
+	Issues   []helpers.Issue
+	// raw report of the auditor
+	Raw string
+	// filetype of the raw report, used by the editor fallback
+	RawType string
+}
+
+type viewerTab string
+
+var (
+	summaryTab viewerTab = "summary"
+	issuesTab  viewerTab = "issues"
+	rawTab     viewerTab = "raw"
+)
+
+var viewerSeverities = []string{"", helpers.SeverityError, helpers.SeverityWarning, helpers.SeverityNotice}
+
+// issueGroup is the issues of a single rule, which can be expanded for listing each of them
+type issueGroup struct {
+	tool     string
+	rule     string
+	message  string
+	severity string
+	issues   []helpers.Issue
+}
+
+var (
+	viewerHelpStyle      = lipgloss.NewStyle().Faint(true)
+	viewerActiveTabStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+	viewerCursorStyle    = lipgloss.NewStyle().Reverse(true)
+	viewerSeverityStyles = map[string]lipgloss.Style{
+		helpers.SeverityError:   lipgloss.NewStyle().Foreground(lipgloss.Color("#fa2a55")),
+		helpers.SeverityWarning: lipgloss.NewStyle().Foreground(lipgloss.Color("#ffb86c")),
+		helpers.SeverityNotice:  lipgloss.NewStyle().Foreground(lipgloss.Color("#addfff")),
+	}
+)
+
+type ViewerModel struct {
+	doc   Document
+	tabs  []viewerTab
+	tab   int
+	style string
+
+	width  int
+	height int
+	ready  bool
+
+	// summary and raw tabs
+	viewport viewport.Model
+	content  string
+	matches  []int
+	match    int
+
+	// issues tab
+	groups   []issueGroup
+	visible  []issueGroup
+	expanded map[string]bool
+	cursor   int
+	offset   int
+	severity int
+	rule     string
+
+	searching bool
+	search    textinput.Model
+	query     string
+}
+
+// NewViewerModel creates the viewer, style is the glamour style used for rendering the markdown, e.g.
+// `dark` or `light`
+func NewViewerModel(doc Document, style string) ViewerModel {
+	var tabs []viewerTab
+
+	if doc.Markdown != "" {
+		tabs = append(tabs, summaryTab)
+	}
+
+	if len(doc.Issues) != 0 || doc.Markdown == "" {
+		tabs = append(tabs, issuesTab)
+	}
+
+	if doc.Raw != "" {
+		tabs = append(tabs, rawTab)
+	}
+
+	search := textinput.New()
+	search.Prompt = "/"
+
+	return ViewerModel{
+		doc:      doc,
+		tabs:     tabs,
+		style:    style,
+		groups:   groupIssues(doc.Issues),
+		expanded: make(map[string]bool),
+		search:   search,
+	}
+}
+
+// groupIssues groups the issues by their rule, the most severe and most frequent rules first
+func groupIssues(issues []helpers.Issue) []issueGroup {
+	var groups []issueGroup
+	indexes := make(map[string]int)
+
+	for _, issue := range issues {
+		key := issue.Tool + "|" + issue.Rule
+
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, issueGroup{tool: issue.Tool, rule: issue.Rule, message: issue.Message, severity: issue.Severity})
+		}
+
+		groups[i].issues = append(groups[i].issues, issue)
+
+		if severityRank(issue.Severity) < severityRank(groups[i].severity) {
+			groups[i].severity = issue.Severity
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if severityRank(groups[i].severity) != severityRank(groups[j].severity) {
+			return severityRank(groups[i].severity) < severityRank(groups[j].severity)
+		}
+
+		return len(groups[i].issues) > len(groups[j].issues)
+	})
+
+	return groups
+}
+
+func severityRank(severity string) int {
+	for i, s := range viewerSeverities[1:] {
+		if s == severity {
+			return i
+		}
+	}
+
+	return len(viewerSeverities)
+}
+
+func (g issueGroup) key() string {
+	return g.tool + "|" + g.rule
+}
+
+func (m ViewerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ViewerModel) currentTab() viewerTab {
+	return m.tabs[m.tab]
+}
+
+func (m ViewerModel) contentHeight() int {
+	// title, tabs and status line take up three lines
+	return max(m.height-3, 1)
+}
+
+func (m ViewerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, m.contentHeight())
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = m.contentHeight()
+		}
+
+		m.search.Width = msg.Width - 2
+		m.refresh()
+
+		return m, nil
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "esc":
+			if m.query == "" && m.rule == "" && m.severity == 0 {
+				return m, tea.Quit
+			}
+
+			m.query = ""
+			m.rule = ""
+			m.severity = 0
+			m.search.SetValue("")
+			m.refresh()
+
+			return m, nil
+		case "tab":
+			m.tab = (m.tab + 1) % len(m.tabs)
+			m.refresh()
+			return m, nil
+		case "shift+tab":
+			m.tab = (m.tab - 1 + len(m.tabs)) % len(m.tabs)
+			m.refresh()
+			return m, nil
+		case "/":
+			m.searching = true
+			m.search.Focus()
+			return m, textinput.Blink
+		}
+
+		if m.currentTab() == issuesTab {
+			m.updateIssues(msg)
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "n":
+			m.jumpToMatch(m.match + 1)
+			return m, nil
+		case "N":
+			m.jumpToMatch(m.match - 1)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+
+	return m, cmd
+}
+
+func (m ViewerModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.searching = false
+		m.search.Blur()
+		m.search.SetValue(m.query)
+		return m, nil
+	case tea.KeyEnter:
+		m.searching = false
+		m.search.Blur()
+		m.query = m.search.Value()
+		m.refresh()
+
+		if m.currentTab() != issuesTab {
+			m.jumpToMatch(0)
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+
+	// the issues are filtered as you type
+	if m.currentTab() == issuesTab {
+		m.query = m.search.Value()
+		m.refresh()
+	}
+
+	return m, cmd
+}
+
+func (m *ViewerModel) updateIssues(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.contentHeight()
+	case "pgdown":
+		m.cursor += m.contentHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.visible) - 1
+	case "enter", " ":
+		if len(m.visible) != 0 {
+			key := m.visible[m.cursor].key()
+			m.expanded[key] = !m.expanded[key]
+		}
+	case "e":
+		expand := false
+		for _, group := range m.visible {
+			if !m.expanded[group.key()] {
+				expand = true
+			}
+		}
+
+		for _, group := range m.visible {
+			m.expanded[group.key()] = expand
+		}
+	case "s":
+		m.severity = (m.severity + 1) % len(viewerSeverities)
+	case "r":
+		if m.rule != "" {
+			m.rule = ""
+		} else if len(m.visible) != 0 {
+			m.rule = m.visible[m.cursor].rule
+		}
+	}
+
+	m.refresh()
+}
+
+// refresh re-applies the filters and re-renders the content of the current tab
+func (m *ViewerModel) refresh() {
+	if !m.ready {
+		return
+	}
+
+	switch m.currentTab() {
+	case summaryTab:
+		m.content = renderMarkdown(m.doc.Markdown, m.style, m.width)
+	case rawTab:
+		m.content = lipgloss.NewStyle().Width(m.width).Render(m.doc.Raw)
+	case issuesTab:
+		m.filterIssues()
+		m.scrollToCursor()
+		return
+	}
+
+	m.viewport.SetContent(m.content)
+	m.findMatches()
+}
+
+// renderMarkdown renders the markdown with the glamour style, returning it as is if it can't be rendered
+func renderMarkdown(markdown string, style string, width int) string {
+	renderer, err := glamour.NewTermRenderer(glamour.WithStandardStyle(style), glamour.WithWordWrap(max(width-4, 20)))
+	if err != nil {
+		return markdown
+	}
+
+	rendered, err := renderer.Render(markdown)
+	if err != nil {
+		return markdown
+	}
+
+	return rendered
+}
+
+// findMatches finds the lines of the viewport which contain the query
+func (m *ViewerModel) findMatches() {
+	m.matches = nil
+	m.match = 0
+
+	if m.query == "" {
+		return
+	}
+
+	query := strings.ToLower(m.query)
+
+	for i, line := range strings.Split(m.content, "\n") {
+		if strings.Contains(strings.ToLower(ansi.Strip(line)), query) {
+			m.matches = append(m.matches, i)
+		}
+	}
+}
+
+func (m *ViewerModel) jumpToMatch(match int) {
+	if len(m.matches) == 0 {
+		return
+	}
+
+	m.match = (match + len(m.matches)) % len(m.matches)
+	m.viewport.SetYOffset(m.matches[m.match])
+}
+
+func (m *ViewerModel) filterIssues() {
+	query := strings.ToLower(m.query)
+	severity := viewerSeverities[m.severity]
+
+	m.visible = nil
+
+	for _, group := range m.groups {
+		filtered := group
+		filtered.issues = nil
+
+		for _, issue := range group.issues {
+			if severity != "" && issue.Severity != severity {
+				continue
+			}
+
+			if m.rule != "" && issue.Rule != m.rule {
+				continue
+			}
+
+			if query != "" && !strings.Contains(strings.ToLower(strings.Join([]string{issue.Rule, issue.Message, issue.Selector, issue.Context}, " ")), query) {
+				continue
+			}
+
+			filtered.issues = append(filtered.issues, issue)
+		}
+
+		if len(filtered.issues) != 0 {
+			m.visible = append(m.visible, filtered)
+		}
+	}
+
+	m.cursor = max(min(m.cursor, len(m.visible)-1), 0)
+}
+
+// issueLines renders the visible groups, returning the lines along with the line of the cursor
+func (m ViewerModel) issueLines() ([]string, int) {
+	var lines []string
+	cursorLine := 0
+
+	for i, group := range m.visible {
+		marker := "▸"
+		if m.expanded[group.key()] {
+			marker = "▾"
+		}
+
+		severity := viewerSeverityStyles[group.severity].Render(fmt.Sprintf("%-7s", group.severity))
+		line := ansi.Truncate(fmt.Sprintf("%s %s %s (%d)  %s", marker, severity, group.rule, len(group.issues), group.message), m.width, "…")
+
+		if i == m.cursor {
+			line = viewerCursorStyle.Render(ansi.Strip(line))
+			cursorLine = len(lines)
+		}
+
+		lines = append(lines, line)
+
+		if !m.expanded[group.key()] {
+			continue
+		}
+
+		for _, issue := range group.issues {
+			detail := issue.Selector
+			if detail == "" {
+				detail = issue.Message
+			}
+
+			lines = append(lines, ansi.Truncate(fmt.Sprintf("    • %s%s", detail, describeIssueStatus(issue)), m.width, "…"))
+
+			if issue.Context != "" {
+				lines = append(lines, viewerHelpStyle.Render(ansi.Truncate("      "+strings.Join(strings.Fields(issue.Context), " "), m.width, "…")))
+			}
+		}
+	}
+
+	return lines, cursorLine
+}
+
+func describeIssueStatus(issue helpers.Issue) string {
+	switch issue.Status {
+	case helpers.IssueNew:
+		return " " + styles.BoldPinkTextStyle.Render("new")
+	case helpers.IssuePersisting:
+		return viewerHelpStyle.Render(fmt.Sprintf(" since %s", issue.FirstSeen.Local().Format("2006-01-02")))
+	default:
+		return ""
+	}
+}
+
+// scrollToCursor keeps the cursor on screen
+func (m *ViewerModel) scrollToCursor() {
+	lines, cursorLine := m.issueLines()
+	height := m.contentHeight()
+
+	if cursorLine < m.offset {
+		m.offset = cursorLine
+	} else if cursorLine >= m.offset+height {
+		m.offset = cursorLine - height + 1
+	}
+
+	m.offset = max(min(m.offset, len(lines)-height), 0)
+}
+
+func (m ViewerModel) issuesView() string {
+	if len(m.visible) == 0 {
+		return "no issues found"
+	}
+
+	lines, _ := m.issueLines()
+	end := min(m.offset+m.contentHeight(), len(lines))
+
+	return strings.Join(lines[m.offset:end], "\n")
+}
+
+func (m ViewerModel) View() string {
+	if !m.ready {
+		return "loading..."
+	}
+
+	var tabs []string
+	for i, tab := range m.tabs {
+		if i == m.tab {
+			tabs = append(tabs, viewerActiveTabStyle.Render(string(tab)))
+		} else {
+			tabs = append(tabs, viewerHelpStyle.Render(string(tab)))
+		}
+	}
+
+	var content, status string
+
+	if m.currentTab() == issuesTab {
+		content = m.issuesView()
+		// the content is padded, so that the status line stays at the bottom
+		content += strings.Repeat("\n", max(m.contentHeight()-lipgloss.Height(content), 0))
+
+		var filters []string
+		if severity := viewerSeverities[m.severity]; severity != "" {
+			filters = append(filters, "severity: "+severity)
+		}
+
+		if m.rule != "" {
+			filters = append(filters, "rule: "+m.rule)
+		}
+
+		if m.query != "" {
+			filters = append(filters, "search: "+m.query)
+		}
+
+		status = "↑/↓: move • enter: expand • e: expand all • s: severity • r: rule • /: search • tab: switch • q: quit"
+		if len(filters) != 0 {
+			status = strings.Join(filters, " • ") + " • esc: clear"
+		}
+	} else {
+		content = m.viewport.View()
+
+		status = "↑/↓: scroll • /: search • n/N: next/previous match • tab: switch • q: quit"
+		if m.query != "" {
+			status = fmt.Sprintf("%d match(es) for %q • n/N: next/previous match • esc: clear", len(m.matches), m.query)
+		}
+	}
+
+	if m.searching {
+		status = m.search.View()
+	} else {
+		status = viewerHelpStyle.Render(ansi.Truncate(status, m.width, "…"))
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", styles.BoldBlueTextStyle.Render(ansi.Truncate(m.doc.Title, m.width, "…")), strings.Join(tabs, "  "), content, status)
+}
@@ -2,9 +2,12 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func IsValidUrl(url string) bool {
@@ -51,10 +54,31 @@ func MaskApiKey(key string) string {
 }
 
 func LogF(msg string) {
-	fmt.Println(msg)
+	LogFTo(os.Stdout, msg)
+}
+
+// LogFTo is LogF which prints the message to the given writer, e.g. stderr when stdout is piped
+func LogFTo(w io.Writer, msg string) {
+	fmt.Fprintln(w, msg)
 	os.Exit(1)
 }
 
 func Remove(slice []int, s int) []int {
 	return append(slice[:s], slice[s+1:]...)
 }
+
+// ParseDuration is like time.ParseDuration, but also accepts days and weeks, e.g. `30d` or `2w`
+func ParseDuration(duration string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if value, found := strings.CutSuffix(duration, suffix); found {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %s", duration)
+			}
+
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(duration)
+}